@@ -0,0 +1,100 @@
+package jsonsearcher
+
+import "fmt"
+
+// TypeError is returned by the error-returning getters when the result is of another type.
+// Actual is the undefined type(resultType(0)) if the result does not exist
+type TypeError struct {
+	Path     Path
+	Expected resultType
+	Actual   resultType
+}
+
+func (e *TypeError) Error() string {
+	if e.Actual == resultType(0) {
+		return fmt.Sprintf("jsonsearcher: %v does not exist, expected %v", e.Path, e.Expected)
+	}
+	return fmt.Sprintf("jsonsearcher: %v is %v, expected %v", e.Path, e.Actual, e.Expected)
+}
+
+// check returns a *TypeError if the result is not of the expected type, or the error of decoding the
+// value of a lazy result
+func (r *Result) check(expected resultType) error {
+	if !r.exists || r.resType != expected {
+		return &TypeError{Path: r.path, Expected: expected, Actual: r.resType}
+	}
+	if r.lazy != nil {
+		r.val()
+		return r.lazy.err
+	}
+	return nil
+}
+
+// Int64 is like GetInt64 but returns a *TypeError instead of panicking. The number is converted exactly,
+// a *NumberError is returned if it has a fractional part or overflows
+func (r *Result) Int64() (int64, error) {
+	if err := r.check(TypeNumber); err != nil {
+		return 0, err
+	}
+	return r.exactInt64()
+}
+
+// Uint64 is like GetUint64 but returns a *TypeError instead of panicking. The number is converted exactly,
+// a *NumberError is returned if it has a fractional part or overflows
+func (r *Result) Uint64() (uint64, error) {
+	if err := r.check(TypeNumber); err != nil {
+		return 0, err
+	}
+	return r.exactUint64()
+}
+
+// Float64 is like GetFloat64 but returns a *TypeError instead of panicking. A json.Number of UseNumber
+// mode returns a *NumberError if it overflows float64
+func (r *Result) Float64() (float64, error) {
+	if err := r.check(TypeNumber); err != nil {
+		return 0, err
+	}
+	f, ok := toFloat(r.val())
+	if !ok {
+		return 0, &NumberError{Path: r.path, Literal: fmt.Sprint(r.val()), Target: "float64", Reason: "overflow"}
+	}
+	return f, nil
+}
+
+// Bool is like GetBool but returns a *TypeError instead of panicking
+func (r *Result) Bool() (bool, error) {
+	if err := r.check(TypeBool); err != nil {
+		return false, err
+	}
+	return r.val().(bool), nil
+}
+
+// StringValue is like GetString but returns a *TypeError instead of panicking. It is not named String
+// so that a Result does not look like a fmt.Stringer
+func (r *Result) StringValue() (string, error) {
+	if err := r.check(TypeString); err != nil {
+		return "", err
+	}
+	return r.val().(string), nil
+}
+
+// Object is like GetObject but returns a *TypeError instead of panicking
+func (r *Result) Object() (map[string]interface{}, error) {
+	if err := r.check(TypeObject); err != nil {
+		return nil, err
+	}
+	return r.val().(map[string]interface{}), nil
+}
+
+// Array is like GetArray but returns a *TypeError instead of panicking
+func (r *Result) Array() ([]interface{}, error) {
+	if err := r.check(TypeArray); err != nil {
+		return nil, err
+	}
+	return r.val().([]interface{}), nil
+}
+
+// Null returns a *TypeError if the result is not null
+func (r *Result) Null() error {
+	return r.check(TypeNull)
+}
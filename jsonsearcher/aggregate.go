@@ -0,0 +1,236 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"math/big"
+	"strconv"
+)
+
+// The aggregate functions compute over the results of a multi-match query, such as:
+//
+//	ages, _ := s.QueryAll("$.friends[*].age")
+//	sum, err := jsonsearcher.Sum(ages)
+//
+// The numbers are computed exactly if all of them are json.Number(UseNumber mode), otherwise they are
+// computed as float64. A *TypeError is returned for the first result which is not a number
+
+// Count returns the count of the existing results
+func Count(results []*Result) int {
+	n := 0
+	for _, r := range results {
+		if r.exists {
+			n++
+		}
+	}
+	return n
+}
+
+// numbers returns the exact values of the results if all of them are json.Number, otherwise the float64
+// values. A *NumberError is returned for the first number which can not be converted
+func numbers(results []*Result) ([]*big.Rat, []float64, error) {
+	exact := true
+	for _, r := range results {
+		if err := r.check(TypeNumber); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := r.val().(stdjson.Number); !ok {
+			exact = false
+		}
+	}
+	if exact {
+		rats := make([]*big.Rat, 0, len(results))
+		for _, r := range results {
+			lit := string(r.val().(stdjson.Number))
+			x, reason := parseRat(lit)
+			if x == nil {
+				return nil, nil, &NumberError{Path: r.path, Literal: lit, Target: "big.Rat", Reason: reason}
+			}
+			rats = append(rats, x)
+		}
+		return rats, nil, nil
+	}
+	floats := make([]float64, 0, len(results))
+	for _, r := range results {
+		f, ok := toFloat(r.val())
+		if !ok {
+			lit, _ := r.numberLiteral()
+			return nil, nil, &NumberError{Path: r.path, Literal: lit, Target: "float64", Reason: "overflow"}
+		}
+		floats = append(floats, f)
+	}
+	return nil, floats, nil
+}
+
+// aggregated returns a number result which is computed from the results. It has no location in the
+// document, so its path is nil
+func aggregated(v interface{}, results []*Result) *Result {
+	r := newResult(v, nil)
+	if len(results) > 0 {
+		r.src = results[0].src
+	}
+	return r
+}
+
+// Sum returns the sum of the numbers, it's 0 if results is empty
+func Sum(results []*Result) (*Result, error) {
+	rats, floats, err := numbers(results)
+	if err != nil {
+		return nil, err
+	}
+	if len(rats) > 0 {
+		sum := new(big.Rat)
+		for _, x := range rats {
+			sum.Add(sum, x)
+		}
+		lit, _ := ratLiteral(sum)
+		return aggregated(stdjson.Number(lit), results), nil
+	}
+	sum := 0.0
+	for _, f := range floats {
+		sum += f
+	}
+	return aggregated(sum, results), nil
+}
+
+// Avg returns the arithmetic mean of the numbers, the result does not exist if results is empty.
+// In UseNumber mode the mean is exact if it's a finite decimal, otherwise it's rounded to float64
+func Avg(results []*Result) (*Result, error) {
+	rats, floats, err := numbers(results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &Result{}, nil
+	}
+	if len(rats) > 0 {
+		avg := new(big.Rat)
+		for _, x := range rats {
+			avg.Add(avg, x)
+		}
+		avg.Quo(avg, big.NewRat(int64(len(rats)), 1))
+		lit, ok := ratLiteral(avg)
+		if !ok {
+			f, _ := avg.Float64()
+			lit = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return aggregated(stdjson.Number(lit), results), nil
+	}
+	sum := 0.0
+	for _, f := range floats {
+		sum += f
+	}
+	return aggregated(sum/float64(len(floats)), results), nil
+}
+
+// Min returns the result of the least number, the first one is returned if there are equal ones.
+// The result does not exist if results is empty
+func Min(results []*Result) (*Result, error) {
+	return extreme(results, -1)
+}
+
+// Max is like Min but returns the result of the greatest number
+func Max(results []*Result) (*Result, error) {
+	return extreme(results, 1)
+}
+
+func extreme(results []*Result, sign int) (*Result, error) {
+	rats, floats, err := numbers(results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &Result{}, nil
+	}
+	best := 0
+	for i := 1; i < len(results); i++ {
+		var cmp int
+		if rats != nil {
+			cmp = rats[i].Cmp(rats[best])
+		} else {
+			switch {
+			case floats[i] < floats[best]:
+				cmp = -1
+			case floats[i] > floats[best]:
+				cmp = 1
+			}
+		}
+		if cmp == sign {
+			best = i
+		}
+	}
+	return results[best], nil
+}
+
+// Distinct returns the results of distinct values in their original order, the first one is kept if
+// there are equal values. Values of any type are accepted, and numbers are compared by their values
+func Distinct(results []*Result) []*Result {
+	var distinct []*Result
+	// The values are grouped by a hash key, so only the values in the same group are compared. Strings,
+	// booleans and null are equal if their keys are equal, numbers are grouped by their float64 values,
+	// and the objects or the arrays are in one group
+	seen := make(map[distinctKey][]interface{})
+outer:
+	for _, r := range results {
+		if !r.exists {
+			continue
+		}
+		v := r.val()
+		key := distinctKey{typ: r.resType}
+		switch x := v.(type) {
+		case string:
+			key.str = x
+		case bool:
+			key.str = strconv.FormatBool(x)
+		case float64, stdjson.Number:
+			// A number out of the range of float64 is grouped into ±Inf or 0
+			f, _ := toFloat(x)
+			key.str = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		switch r.resType {
+		case TypeString, TypeBool, TypeNull:
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = nil
+		default:
+			for _, s := range seen[key] {
+				if jsonEqual(v, s) {
+					continue outer
+				}
+			}
+			seen[key] = append(seen[key], v)
+		}
+		distinct = append(distinct, r)
+	}
+	return distinct
+}
+
+type distinctKey struct {
+	typ resultType
+	str string
+}
+
+// ratLiteral formats x as a decimal literal, ok is false if x is not a finite decimal such as 1/3
+func ratLiteral(x *big.Rat) (string, bool) {
+	d := new(big.Int).Set(x.Denom())
+	mod := new(big.Int)
+	scale := 0
+	for _, p := range []int64{2, 5} {
+		prime := big.NewInt(p)
+		n := 0
+		for {
+			q, m := new(big.Int).QuoRem(d, prime, mod)
+			if m.Sign() != 0 {
+				break
+			}
+			d, n = q, n+1
+		}
+		if n > scale {
+			scale = n
+		}
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", false
+	}
+	return x.FloatString(scale), true
+}
@@ -0,0 +1,171 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CoerceError is returned by the coercing getters when the value can not be converted into the target type
+type CoerceError struct {
+	Path Path
+	// Actual is the type of the value, it's the undefined type(resultType(0)) if the result does not exist
+	Actual resultType
+	// Target is the go type converted into, such as int64
+	Target string
+	Msg    string
+}
+
+func (e *CoerceError) Error() string {
+	return fmt.Sprintf("jsonsearcher: can not coerce %v(%v) into %v: %v", e.Path, e.Actual, e.Target, e.Msg)
+}
+
+func (r *Result) coerceError(target string, format string, args ...interface{}) error {
+	return &CoerceError{Path: r.path, Actual: r.resType, Target: target, Msg: fmt.Sprintf(format, args...)}
+}
+
+// asNumber returns the result as a number: a number is returned as it is, and a string holding a json
+// number literal such as "16" or "-1.5e3" is converted into a number result
+func (r *Result) asNumber(target string) (*Result, error) {
+	if !r.exists {
+		return nil, r.coerceError(target, "the value does not exist")
+	}
+	switch r.resType {
+	case TypeNumber:
+		return r, nil
+	case TypeString:
+		str := r.val().(string)
+		if !isNumberLiteral(str) {
+			return nil, r.coerceError(target, "%q is not a number", str)
+		}
+		return &Result{resType: TypeNumber, exists: true, path: r.path, value: stdjson.Number(str), src: r.src}, nil
+	default:
+		return nil, r.coerceError(target, "%v can not be converted into a number", r.resType)
+	}
+}
+
+// isNumberLiteral reports whether str is a json number without surrounding spaces
+func isNumberLiteral(str string) bool {
+	if str == "" {
+		return false
+	}
+	end, err := skipNumber([]byte(str), 0)
+	return err == nil && end == len(str)
+}
+
+// AsInt64 converts the value into int64. Numbers and strings of json numbers such as "16" are accepted,
+// and a *NumberError is returned if the number has a fractional part or overflows
+func (r *Result) AsInt64() (int64, error) {
+	n, err := r.asNumber("int64")
+	if err != nil {
+		return 0, err
+	}
+	return n.exactInt64()
+}
+
+// AsUint64 is like AsInt64 but converts the value into uint64
+func (r *Result) AsUint64() (uint64, error) {
+	n, err := r.asNumber("uint64")
+	if err != nil {
+		return 0, err
+	}
+	return n.exactUint64()
+}
+
+// AsFloat64 converts the value into float64, numbers and strings of json numbers are accepted
+func (r *Result) AsFloat64() (float64, error) {
+	n, err := r.asNumber("float64")
+	if err != nil {
+		return 0, err
+	}
+	return n.Float64()
+}
+
+// AsBool converts the value into bool. Booleans, the numbers 1 and 0, and the strings "true", "false",
+// "1" and "0"(case-insensitive) are accepted
+func (r *Result) AsBool() (bool, error) {
+	if !r.exists {
+		return false, r.coerceError("bool", "the value does not exist")
+	}
+	switch r.resType {
+	case TypeBool:
+		return r.val().(bool), nil
+	case TypeNumber:
+		lit, _ := r.numberLiteral()
+		x, _ := parseRat(lit)
+		switch {
+		case x == nil:
+		case x.Cmp(big.NewRat(1, 1)) == 0:
+			return true, nil
+		case x.Sign() == 0:
+			return false, nil
+		}
+		return false, r.coerceError("bool", "only 1 and 0 can be converted, got %v", lit)
+	case TypeString:
+		switch str := r.val().(string); strings.ToLower(str) {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		default:
+			return false, r.coerceError("bool", "%q is not a boolean", str)
+		}
+	default:
+		return false, r.coerceError("bool", "%v can not be converted into a boolean", r.resType)
+	}
+}
+
+// AsString converts the value into string. A number is converted into its literal, and a boolean is
+// converted into "true" or "false". Objects, arrays and null are not accepted
+func (r *Result) AsString() (string, error) {
+	if !r.exists {
+		return "", r.coerceError("string", "the value does not exist")
+	}
+	switch r.resType {
+	case TypeString:
+		return r.val().(string), nil
+	case TypeNumber:
+		return r.numberLiteral()
+	case TypeBool:
+		return strconv.FormatBool(r.val().(bool)), nil
+	default:
+		return "", r.coerceError("string", "%v can not be converted into a string", r.resType)
+	}
+}
+
+// AsDuration converts the value into time.Duration. A string is parsed by time.ParseDuration such as "1h30m",
+// and a number or a string of json number is the count of seconds such as 1.5. A *NumberError is returned
+// if the seconds are more precise than nanoseconds or overflow
+func (r *Result) AsDuration() (time.Duration, error) {
+	if r.exists && r.resType == TypeString {
+		str := r.val().(string)
+		if !isNumberLiteral(str) {
+			d, err := time.ParseDuration(str)
+			if err != nil {
+				return 0, r.coerceError("time.Duration", "%v", err)
+			}
+			return d, nil
+		}
+	}
+
+	n, err := r.asNumber("time.Duration")
+	if err != nil {
+		return 0, err
+	}
+	lit, _ := n.numberLiteral()
+	x, reason := parseRat(lit)
+	if x == nil {
+		return 0, &NumberError{Path: r.path, Literal: lit, Target: "time.Duration", Reason: reason}
+	}
+	x.Mul(x, big.NewRat(int64(time.Second), 1))
+	if !x.IsInt() {
+		return 0, &NumberError{Path: r.path, Literal: lit, Target: "time.Duration", Reason: "fractional part"}
+	}
+	if !x.Num().IsInt64() {
+		return 0, &NumberError{Path: r.path, Literal: lit, Target: "time.Duration", Reason: "overflow"}
+	}
+	return time.Duration(x.Num().Int64()), nil
+}
@@ -0,0 +1,261 @@
+package jsonsearcher
+
+import (
+	"encoding"
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// DecodeOptions controls how a result is decoded into go values
+type DecodeOptions struct {
+	// Strict makes the object members which don't match any struct field an error
+	Strict bool
+}
+
+// DecodeError is returned when a result can not be decoded, Path is the location of the json value
+// which can not be decoded into its go type
+type DecodeError struct {
+	Path Path
+	Msg  string
+	// Err is the error reported by jsoniter
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("jsonsearcher: can not decode %v: %v", e.Path, e.Msg)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeAPIs are the jsoniter configs for Decode, indexed by strict and useNumber
+var decodeAPIs = [2][2]jsoniter.API{}
+
+func init() {
+	for i, strict := range []bool{false, true} {
+		for j, useNumber := range []bool{false, true} {
+			decodeAPIs[i][j] = jsoniter.Config{
+				EscapeHTML:             true,
+				SortMapKeys:            true,
+				ValidateJsonRawMessage: true,
+				UseNumber:              useNumber,
+				DisallowUnknownFields:  strict,
+			}.Froze()
+		}
+	}
+}
+
+// Decode decodes the value of the result into v, which must be a non-nil pointer, as json.Unmarshal does
+func (r *Result) Decode(v interface{}) error {
+	return r.DecodeWithOptions(v, DecodeOptions{})
+}
+
+// DecodeWithOptions is like Decode but decodes with opts. Return a *DecodeError with the json path of
+// the value which can not be decoded
+func (r *Result) DecodeWithOptions(v interface{}, opts DecodeOptions) error {
+	if !r.exists {
+		return &DecodeError{Path: r.path, Msg: "the value does not exist"}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jsonsearcher: Decode needs a non-nil pointer, got %T", v)
+	}
+
+	// The raw bytes are decoded directly if they are kept, the value is only encoded again when the
+	// searcher doesn't keep them or the document has been mutated
+	data := r.raw()
+	if data == nil {
+		var err error
+		if data, err = encodeValue(r.value, r.src.orderTable(), "", "", false); err != nil {
+			return err
+		}
+	}
+
+	api := decodeAPIs[boolIndex(opts.Strict)][boolIndex(r.src != nil && r.src.useNumber)]
+	if err := api.Unmarshal(data, v); err != nil {
+		l := &decodeLocator{strict: opts.Strict}
+		if r.lazy == nil {
+			l.order = r.src.orderTable()
+		}
+		path, msg, ok := l.locate(r.val(), rv.Type().Elem(), r.path)
+		if !ok {
+			path, msg = r.path, err.Error()
+		}
+		return &DecodeError{Path: path, Msg: msg, Err: err}
+	}
+	return nil
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*stdjson.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeLocator finds the json value which makes decoding fail
+type decodeLocator struct {
+	order  orderTable
+	strict bool
+}
+
+// locate walks the json value v along the go type t, and returns the path of the first value in
+// document order which can not be decoded into its type. ok is false if no such value is found
+func (l *decodeLocator) locate(v interface{}, t reflect.Type, path Path) (Path, string, bool) {
+	for t.Kind() == reflect.Ptr {
+		if v == nil {
+			return nil, "", false
+		}
+		t = t.Elem()
+	}
+	// The custom unmarshalers decide what they accept
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return nil, "", false
+	}
+	if v == nil || t.Kind() == reflect.Interface {
+		return nil, "", false
+	}
+
+	mismatch := func() (Path, string, bool) {
+		return path, fmt.Sprintf("%v can not be decoded into %v", typeOf(v), t), true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		fields := structFields(t)
+		for _, k := range l.order.keys(obj) {
+			f, ok := fieldByName(fields, k)
+			if !ok {
+				if l.strict {
+					return path.child(k), fmt.Sprintf("unknown field %q of %v", k, t), true
+				}
+				continue
+			}
+			if strings.Contains(f.Tag.Get("json"), ",string") {
+				// The value is quoted in a string
+				continue
+			}
+			if p, msg, ok := l.locate(obj[k], f.Type, path.child(k)); ok {
+				return p, msg, true
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for _, k := range l.order.keys(obj) {
+			if p, msg, ok := l.locate(obj[k], t.Elem(), path.child(k)); ok {
+				return p, msg, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if _, ok := v.(string); ok && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is decoded from a base64 string
+			return nil, "", false
+		}
+		arr, ok := v.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for i, elem := range arr {
+			if p, msg, ok := l.locate(elem, t.Elem(), path.child(i)); ok {
+				return p, msg, true
+			}
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return mismatch()
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat(v)
+		if !ok {
+			return mismatch()
+		}
+		if f != math.Trunc(f) {
+			return path, fmt.Sprintf("number %v has a fractional part, can not be decoded into %v", v, t), true
+		}
+		if overflows(f, t) {
+			return path, fmt.Sprintf("number %v overflows %v", v, t), true
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := toFloat(v); !ok {
+			return mismatch()
+		}
+	}
+	return nil, "", false
+}
+
+func overflows(f float64, t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f < 0 || f >= math.Ldexp(1, t.Bits())
+	default:
+		return f < -math.Ldexp(1, t.Bits()-1) || f >= math.Ldexp(1, t.Bits()-1)
+	}
+}
+
+// structFields returns the json fields of a struct type, the fields of embedded structs are promoted
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, structFields(ft)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name != "" {
+			f.Name = name
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// fieldByName finds the field of the json key, the names are compared case-insensitively
+// like encoding/json does
+func fieldByName(fields []reflect.StructField, key string) (reflect.StructField, bool) {
+	for _, f := range fields {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
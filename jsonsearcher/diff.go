@@ -0,0 +1,200 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"math"
+	"sort"
+)
+
+// ChangeKind is the kind of a change between two documents
+type ChangeKind int
+
+const (
+	_ ChangeKind = iota
+	// ChangeAdded means the value only exists in the new document
+	ChangeAdded
+	// ChangeRemoved means the value only exists in the old document
+	ChangeRemoved
+	// ChangeChanged means the value is changed, and its type is not changed
+	ChangeChanged
+	// ChangeTypeChanged means the value is changed into another type
+	ChangeTypeChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "Added"
+	case ChangeRemoved:
+		return "Removed"
+	case ChangeChanged:
+		return "Changed"
+	case ChangeTypeChanged:
+		return "TypeChanged"
+	default:
+		return "InvalidChange"
+	}
+}
+
+// Change is a difference between two documents. Old is nil for ChangeAdded, and New is nil for ChangeRemoved
+type Change struct {
+	Path Path
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+// DiffOptions controls how the documents are compared
+type DiffOptions struct {
+	// IgnorePaths are JSONPath expressions, the values they match in either document are not compared
+	IgnorePaths []string
+	// UnorderedArrays makes arrays compared as unordered sets: elements are matched with equal elements
+	// of the other array wherever they are
+	UnorderedArrays bool
+	// Tolerance is the max difference of two numbers which are treated as equal
+	Tolerance float64
+}
+
+// Diff returns the changes from the document of a to the document of b, object members are compared
+// in the order of their keys
+func Diff(a, b *searcher) []Change {
+	changes, _ := DiffWithOptions(a, b, DiffOptions{})
+	return changes
+}
+
+// DiffWithOptions is like Diff but compares with opts. Return error when a path to ignore is invalid or a
+// lazy document can not be decoded
+func DiffWithOptions(a, b *searcher, opts DiffOptions) ([]Change, error) {
+	d := &differ{opts: opts, ignoredA: make(map[string]bool), ignoredB: make(map[string]bool)}
+	for _, path := range opts.IgnorePaths {
+		for _, pair := range []struct {
+			s       *searcher
+			ignored map[string]bool
+		}{{a, d.ignoredA}, {b, d.ignoredB}} {
+			results, err := pair.s.QueryAll(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range results {
+				pair.ignored[formatPointer(r.path)] = true
+			}
+		}
+	}
+	for _, s := range []*searcher{a, b} {
+		if err := s.decode(); err != nil {
+			return nil, err
+		}
+	}
+	return d.diff(nil, Path{}, Path{}, a.tree(), b.tree()), nil
+}
+
+type differ struct {
+	opts DiffOptions
+	// ignoredA and ignoredB are the JSON Pointers of the values to ignore in the two documents
+	ignoredA map[string]bool
+	ignoredB map[string]bool
+}
+
+// diff appends the changes from a to b to dst, pathA and pathB are the locations of a and b.
+// They are different only if the values are matched in unordered arrays
+func (d *differ) diff(dst []Change, pathA, pathB Path, a, b interface{}) []Change {
+	if d.ignoredA[formatPointer(pathA)] || d.ignoredB[formatPointer(pathB)] {
+		return dst
+	}
+
+	ta, tb := typeOf(a), typeOf(b)
+	if ta != tb {
+		return append(dst, Change{Path: pathB, Kind: ChangeTypeChanged, Old: a, New: b})
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv := b.(map[string]interface{})
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inB:
+				if !d.ignoredA[formatPointer(pathA.child(k))] {
+					dst = append(dst, Change{Path: pathA.child(k), Kind: ChangeRemoved, Old: x})
+				}
+			case !inA:
+				if !d.ignoredB[formatPointer(pathB.child(k))] {
+					dst = append(dst, Change{Path: pathB.child(k), Kind: ChangeAdded, New: y})
+				}
+			default:
+				dst = d.diff(dst, pathA.child(k), pathB.child(k), x, y)
+			}
+		}
+		return dst
+	case []interface{}:
+		if d.opts.UnorderedArrays {
+			return d.diffUnordered(dst, pathA, pathB, av, b.([]interface{}))
+		}
+		bv := b.([]interface{})
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			switch {
+			case i >= len(bv):
+				if !d.ignoredA[formatPointer(pathA.child(i))] {
+					dst = append(dst, Change{Path: pathA.child(i), Kind: ChangeRemoved, Old: av[i]})
+				}
+			case i >= len(av):
+				if !d.ignoredB[formatPointer(pathB.child(i))] {
+					dst = append(dst, Change{Path: pathB.child(i), Kind: ChangeAdded, New: bv[i]})
+				}
+			default:
+				dst = d.diff(dst, pathA.child(i), pathB.child(i), av[i], bv[i])
+			}
+		}
+		return dst
+	case float64, stdjson.Number:
+		if d.opts.Tolerance > 0 {
+			fa, _ := toFloat(a)
+			fb, _ := toFloat(b)
+			if math.Abs(fa-fb) > d.opts.Tolerance {
+				dst = append(dst, Change{Path: pathB, Kind: ChangeChanged, Old: a, New: b})
+			}
+			return dst
+		}
+	}
+
+	if !jsonEqual(a, b) {
+		dst = append(dst, Change{Path: pathB, Kind: ChangeChanged, Old: a, New: b})
+	}
+	return dst
+}
+
+// diffUnordered matches each element of a with an equal element of b, the elements not matched
+// are reported as removed or added
+func (d *differ) diffUnordered(dst []Change, pathA, pathB Path, a, b []interface{}) []Change {
+	matched := make([]bool, len(b))
+	for i, x := range a {
+		found := false
+		for j, y := range b {
+			if !matched[j] && len(d.diff(nil, pathA.child(i), pathB.child(j), x, y)) == 0 {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found && !d.ignoredA[formatPointer(pathA.child(i))] {
+			dst = append(dst, Change{Path: pathA.child(i), Kind: ChangeRemoved, Old: x})
+		}
+	}
+	for j, y := range b {
+		if !matched[j] && !d.ignoredB[formatPointer(pathB.child(j))] {
+			dst = append(dst, Change{Path: pathB.child(j), Kind: ChangeAdded, New: y})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,222 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// encoder writes json values(float64, json.Number, string, bool, nil, []interface{} and map[string]interface{}).
+// The trees are encoded here instead of jsoniter, whose map encoder doesn't work with the map
+// implementation of recent Go versions
+type encoder struct {
+	buf []byte
+	// order keeps the keys of the objects in document order, the keys not recorded are sorted
+	order      orderTable
+	prefix     string
+	indent     string
+	escapeHTML bool
+}
+
+// encodeValue returns the json encoding of v, the output is compact if both prefix and indent are empty.
+// The keys of the objects are written in the order of order
+func encodeValue(v interface{}, order orderTable, prefix, indent string, escapeHTML bool) ([]byte, error) {
+	e := &encoder{order: order, prefix: prefix, indent: indent, escapeHTML: escapeHTML}
+	if err := e.encode(v, 0); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (e *encoder) newline(depth int) {
+	if e.prefix == "" && e.indent == "" {
+		return
+	}
+	e.buf = append(e.buf, '\n')
+	e.buf = append(e.buf, e.prefix...)
+	for i := 0; i < depth; i++ {
+		e.buf = append(e.buf, e.indent...)
+	}
+}
+
+func (e *encoder) encode(v interface{}, depth int) error {
+	switch x := v.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, x)
+	case stdjson.Number:
+		e.buf = append(e.buf, x...)
+	case float64:
+		b, err := appendFloat(e.buf, x)
+		if err != nil {
+			return err
+		}
+		e.buf = b
+	case string:
+		e.buf = appendString(e.buf, x, e.escapeHTML)
+	case []interface{}:
+		if len(x) == 0 {
+			e.buf = append(e.buf, "[]"...)
+			return nil
+		}
+		e.buf = append(e.buf, '[')
+		for i, elem := range x {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.newline(depth + 1)
+			if err := e.encode(elem, depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.buf = append(e.buf, ']')
+	case map[string]interface{}:
+		if len(x) == 0 {
+			e.buf = append(e.buf, "{}"...)
+			return nil
+		}
+		e.buf = append(e.buf, '{')
+		for i, k := range e.order.keys(x) {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.newline(depth + 1)
+			e.buf = appendString(e.buf, k, e.escapeHTML)
+			e.buf = append(e.buf, ':')
+			if e.prefix != "" || e.indent != "" {
+				e.buf = append(e.buf, ' ')
+			}
+			if err := e.encode(x[k], depth+1); err != nil {
+				return err
+			}
+		}
+		e.newline(depth)
+		e.buf = append(e.buf, '}')
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	return nil
+}
+
+// appendFloat formats f like encoding/json
+func appendFloat(b []byte, f float64) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("unsupported value %v", f)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b = strconv.AppendFloat(b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends the quoted string like encoding/json, invalid UTF-8 is replaced with U+FFFD
+func appendString(b []byte, s string, escapeHTML bool) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!escapeHTML || (c != '<' && c != '>' && c != '&')) {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// toJSONValue converts v into json values. A *Result is replaced with its value, and the trees are
+// copied so that they are never shared
+func toJSONValue(v interface{}, dec jsoniter.API) (interface{}, error) {
+	if r, ok := v.(*Result); ok {
+		if !r.Exists() {
+			return nil, fmt.Errorf("the result of %v does not exist", r.Path())
+		}
+		v = r.val()
+	}
+	switch x := v.(type) {
+	case nil, bool, float64, stdjson.Number, string:
+		return v, nil
+	case []interface{}:
+		arr := make([]interface{}, len(x))
+		for i, elem := range x {
+			c, err := toJSONValue(elem, dec)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = c
+		}
+		return arr, nil
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(x))
+		for k, elem := range x {
+			c, err := toJSONValue(elem, dec)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = c
+		}
+		return obj, nil
+	}
+
+	// Other go values are converted through the standard json encoding, jsoniter can't encode maps
+	data, err := stdjson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var jv interface{}
+	if err := dec.Unmarshal(data, &jv); err != nil {
+		return nil, err
+	}
+	return jv, nil
+}
@@ -0,0 +1,397 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// filterExpr is a predicate of a filter selector, such as [?(@.age >= 18 && @.email)]
+type filterExpr interface {
+	// match reports whether the current value(@) satisfies the predicate
+	match(current *Result, root interface{}) bool
+}
+
+type orExpr struct {
+	left, right filterExpr
+}
+
+func (e *orExpr) match(current *Result, root interface{}) bool {
+	return e.left.match(current, root) || e.right.match(current, root)
+}
+
+type andExpr struct {
+	left, right filterExpr
+}
+
+func (e *andExpr) match(current *Result, root interface{}) bool {
+	return e.left.match(current, root) && e.right.match(current, root)
+}
+
+type notExpr struct {
+	x filterExpr
+}
+
+func (e *notExpr) match(current *Result, root interface{}) bool {
+	return !e.x.match(current, root)
+}
+
+// existExpr is satisfied when the query selects at least one value
+type existExpr struct {
+	q *queryOperand
+}
+
+func (e *existExpr) match(current *Result, root interface{}) bool {
+	return len(e.q.results(current, root)) > 0
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e *compareExpr) match(current *Result, root interface{}) bool {
+	lv, lok := e.left.value(current, root)
+	rv, rok := e.right.value(current, root)
+	if !lok || !rok {
+		// A missing value only equals another missing value
+		switch e.op {
+		case "==", "<=", ">=":
+			return !lok && !rok
+		case "!=":
+			return lok != rok
+		}
+		return false
+	}
+
+	switch e.op {
+	case "==":
+		return jsonEqual(lv, rv)
+	case "!=":
+		return !jsonEqual(lv, rv)
+	}
+
+	switch l := lv.(type) {
+	case float64, stdjson.Number:
+		cmp, ok := compareNumbers(l, rv)
+		if !ok {
+			return false
+		}
+		switch e.op {
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		case ">=":
+			return cmp >= 0
+		}
+	case string:
+		r, ok := rv.(string)
+		if !ok {
+			return false
+		}
+		switch e.op {
+		case "<":
+			return l < r
+		case "<=":
+			return l <= r
+		case ">":
+			return l > r
+		case ">=":
+			return l >= r
+		}
+	}
+	return false
+}
+
+// matchExpr is satisfied when the value is a string matching the regular expression
+type matchExpr struct {
+	left operand
+	re   *regexp.Regexp
+}
+
+func (e *matchExpr) match(current *Result, root interface{}) bool {
+	v, ok := e.left.value(current, root)
+	if !ok {
+		return false
+	}
+	str, ok := v.(string)
+	return ok && e.re.MatchString(str)
+}
+
+// operand is a side of a comparison, which is a literal or a singular query
+type operand interface {
+	value(current *Result, root interface{}) (interface{}, bool)
+}
+
+type literalOperand struct {
+	v interface{}
+}
+
+func (o *literalOperand) value(current *Result, root interface{}) (interface{}, bool) {
+	return o.v, true
+}
+
+// queryOperand is a query relative to the current value(@) or the root value($)
+type queryOperand struct {
+	fromRoot bool
+	segs     []segment
+}
+
+func (o *queryOperand) results(current *Result, root interface{}) []*Result {
+	if o.fromRoot {
+		return evaluateFrom(current.src.result(root, Path{}), o.segs, root)
+	}
+	return evaluateFrom(current, o.segs, root)
+}
+
+func (o *queryOperand) value(current *Result, root interface{}) (interface{}, bool) {
+	results := o.results(current, root)
+	if len(results) == 0 {
+		return nil, false
+	}
+	return results[0].val(), true
+}
+
+// parseFilter parses the expression after ?, such as (@.age >= 18 && @.email)
+func (p *pathParser) parseFilter() (filterExpr, error) {
+	return p.parseOr()
+}
+
+func (p *pathParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "||") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+}
+
+func (p *pathParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "&&") {
+			return left, nil
+		}
+		p.pos += 2
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+}
+
+func (p *pathParser) parseUnary() (filterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *pathParser) parsePrimary() (filterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '(' {
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return x, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := p.parseCompareOp()
+	if op == "" {
+		q, ok := left.(*queryOperand)
+		if !ok {
+			return nil, p.errorf("expected comparison after literal")
+		}
+		return &existExpr{q: q}, nil
+	}
+	if err := p.checkSingular(left); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if op == "=~" {
+		re, err := p.parseRegexp()
+		if err != nil {
+			return nil, err
+		}
+		return &matchExpr{left: left, re: re}, nil
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkSingular(right); err != nil {
+		return nil, err
+	}
+	return &compareExpr{op: op, left: left, right: right}, nil
+}
+
+// checkSingular makes sure that a compared query selects at most one value
+func (p *pathParser) checkSingular(o operand) error {
+	if q, ok := o.(*queryOperand); ok && !singular(q.segs) {
+		return p.errorf("query in comparison must select a single value")
+	}
+	return nil
+}
+
+func (p *pathParser) parseCompareOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *pathParser) parseOperand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		var segs []segment
+		for p.peek() == '.' || p.peek() == '[' {
+			seg, err := p.parseSegment()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+		}
+		return &queryOperand{fromRoot: c == '$', segs: segs}, nil
+	case c == '\'' || c == '"':
+		str, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return &literalOperand{v: str}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if _, err := strconv.ParseFloat(p.src[start:p.pos], 64); err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number")
+		}
+		// The literal is kept so that it can be compared exactly with the numbers of UseNumber mode
+		return &literalOperand{v: stdjson.Number(p.src[start:p.pos])}, nil
+	}
+
+	for _, kw := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(p.src[p.pos:], kw) && !isNameChar(p.src, p.pos+len(kw)) {
+			p.pos += len(kw)
+			switch kw {
+			case "true":
+				return &literalOperand{v: true}, nil
+			case "false":
+				return &literalOperand{v: false}, nil
+			}
+			return &literalOperand{v: nil}, nil
+		}
+	}
+	if p.eof() {
+		return nil, p.errorf("unexpected end of filter")
+	}
+	return nil, p.errorf("unexpected character %q in filter", p.peek())
+}
+
+// parseRegexp parses the right side of =~, which is /pattern/flags or a quoted string.
+// The flags i, m and s are supported
+func (p *pathParser) parseRegexp() (*regexp.Regexp, error) {
+	start := p.pos
+	var pattern string
+	switch p.peek() {
+	case '\'', '"':
+		str, err := p.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		pattern = str
+	case '/':
+		p.pos++
+		var sb strings.Builder
+		for {
+			if p.eof() {
+				return nil, p.errorf("unterminated regular expression")
+			}
+			c := p.src[p.pos]
+			p.pos++
+			if c == '/' {
+				break
+			}
+			if c == '\\' && p.peek() == '/' {
+				c = '/'
+				p.pos++
+			} else if c == '\\' && !p.eof() {
+				sb.WriteByte(c)
+				c = p.src[p.pos]
+				p.pos++
+			}
+			sb.WriteByte(c)
+		}
+		flags := ""
+		for !p.eof() && strings.IndexByte("ims", p.peek()) >= 0 {
+			flags += string(p.peek())
+			p.pos++
+		}
+		pattern = sb.String()
+		if flags != "" {
+			pattern = "(?" + flags + ")" + pattern
+		}
+	default:
+		return nil, p.errorf("expected regular expression")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid regular expression: %v", err)
+	}
+	return re, nil
+}
+
+// isNameChar reports whether src[i] continues an identifier
+func isNameChar(src string, i int) bool {
+	if i >= len(src) {
+		return false
+	}
+	c := src[i]
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
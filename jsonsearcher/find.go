@@ -0,0 +1,27 @@
+package jsonsearcher
+
+// FindPaths walks the whole document in document order(parents before children), and returns the paths
+// of the values matching the predicate. The root is included, whose path is empty. The returned paths
+// can be passed to Query directly: s.Query(path...)
+func (s *searcher) FindPaths(pred func(path Path, r *Result) bool) []Path {
+	var paths []Path
+	order := s.orderTable()
+	var walk func(r *Result)
+	walk = func(r *Result) {
+		if pred(r.path, r) {
+			paths = append(paths, r.path)
+		}
+		switch v := r.value.(type) {
+		case map[string]interface{}:
+			for _, k := range order.keys(v) {
+				walk(r.child(v[k], k))
+			}
+		case []interface{}:
+			for i, elem := range v {
+				walk(r.child(elem, i))
+			}
+		}
+	}
+	walk(s.result(s.tree(), Path{}))
+	return paths
+}
@@ -0,0 +1,226 @@
+package jsonsearcher
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DuplicateKeyPolicy decides what New does with the duplicate keys of an object
+type DuplicateKeyPolicy int
+
+const (
+	// UncheckedDuplicateKeys is the default, the duplicate keys are not checked for speed. The decoded
+	// values keep the last one like encoding/json does, but Raw, Position and the queries of a lazy
+	// searcher find the first one
+	UncheckedDuplicateKeys DuplicateKeyPolicy = iota
+	// LastWins keeps the last value of a duplicate key
+	LastWins
+	// FirstWins keeps the first value of a duplicate key
+	FirstWins
+	// ErrorOnDuplicate makes New return a *DuplicateKeyError
+	ErrorOnDuplicate
+)
+
+// LimitError is returned by New when the json data exceeds a limit of Options, Position is where
+// the limit is exceeded
+type LimitError struct {
+	Position
+	// Limit is the name of the option, such as MaxDepth
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("jsonsearcher: the json exceeds %s(%d) at line %d, column %d", e.Limit, e.Max, e.Line, e.Column)
+}
+
+// DuplicateKeyError is returned by New with the ErrorOnDuplicate policy, Path is the location of the
+// duplicate member and Position is where its key starts
+type DuplicateKeyError struct {
+	Position
+	Path Path
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("jsonsearcher: duplicate key %v at line %d, column %d", e.Path, e.Line, e.Column)
+}
+
+// checked reports whether the json data needs to be checked before it's decoded
+func (opts Options) checked() bool {
+	return opts.MaxDepth > 0 || opts.MaxSize > 0 || opts.MaxStringLength > 0 || opts.MaxKeys > 0 ||
+		opts.DuplicateKeys != UncheckedDuplicateKeys
+}
+
+// check checks the json data against the limits of opts. If the object members are dropped by the
+// duplicate key policy, a copy of data with the dropped members blanked out is returned, so that every
+// way of reading the document sees the same value and the positions are kept
+func check(data []byte, opts Options) ([]byte, error) {
+	if opts.MaxSize > 0 && len(data) > opts.MaxSize {
+		return nil, &LimitError{Position: startPosition.advance(data[:opts.MaxSize]), Limit: "MaxSize", Max: opts.MaxSize}
+	}
+	c := &checker{data: data, opts: opts}
+	if _, err := c.value(skipSpace(data, 0), 0); err != nil {
+		return nil, newSyntaxError(data, err)
+	}
+	if len(c.dropped) == 0 {
+		return data, nil
+	}
+
+	data = append([]byte(nil), data...)
+	for _, span := range c.dropped {
+		// A comma next to the member is dropped too, the one after it or the one before it
+		start, end := span[0], skipSpace(data, span[1])
+		if data[end] == ',' {
+			end++
+		} else {
+			end = span[1]
+			for start--; isSpace(data[start]); start-- {
+			}
+		}
+		for i := start; i < end; i++ {
+			if data[i] != '\n' {
+				data[i] = ' '
+			}
+		}
+	}
+	return data, nil
+}
+
+// checker walks the json data like skipValue does, and checks the limits on the way
+type checker struct {
+	data []byte
+	opts Options
+	// path is the location of the current value
+	path Path
+	// dropped are the spans of the members dropped by the duplicate key policy, from the key to the value
+	dropped [][2]int
+}
+
+func (c *checker) limitError(i int, limit string, max int) error {
+	return &LimitError{Position: startPosition.advance(c.data[:i]), Limit: limit, Max: max}
+}
+
+func (c *checker) value(i int, depth int) (int, error) {
+	if i < len(c.data) {
+		switch c.data[i] {
+		case '{', '[':
+			if c.opts.MaxDepth > 0 && depth >= c.opts.MaxDepth {
+				return i, c.limitError(i, "MaxDepth", c.opts.MaxDepth)
+			}
+			if depth >= maxDepth {
+				return i, depthError(i)
+			}
+			if c.data[i] == '{' {
+				return c.object(i, depth+1)
+			}
+			return c.array(i, depth+1)
+		case '"':
+			return c.string(i)
+		}
+	}
+	return skipValue(c.data, i)
+}
+
+// string checks the decoded length of the string starting at i
+func (c *checker) string(i int) (int, error) {
+	end, err := skipString(c.data, i)
+	if err != nil || c.opts.MaxStringLength <= 0 {
+		return end, err
+	}
+	raw := c.data[i+1 : end-1]
+	n := len(raw)
+	if n > c.opts.MaxStringLength && bytes.IndexByte(raw, '\\') >= 0 {
+		n = len(decodeKey(raw))
+	}
+	if n > c.opts.MaxStringLength {
+		return end, c.limitError(i, "MaxStringLength", c.opts.MaxStringLength)
+	}
+	return end, nil
+}
+
+func (c *checker) object(i int, depth int) (int, error) {
+	data := c.data
+	var err error
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return i + 1, nil
+	}
+	// members maps the keys to the spans of their kept members
+	members := make(map[string][2]int)
+	for n := 1; ; n++ {
+		if i >= len(data) || data[i] != '"' {
+			return i, expectedError(data, i, "string for object key")
+		}
+		if c.opts.MaxKeys > 0 && n > c.opts.MaxKeys {
+			return i, c.limitError(i, "MaxKeys", c.opts.MaxKeys)
+		}
+		start := i
+		if i, err = c.string(i); err != nil {
+			return i, err
+		}
+		key := decodeKey(data[start+1 : i-1])
+		prev, dup := members[key]
+		if dup && c.opts.DuplicateKeys == ErrorOnDuplicate {
+			return i, &DuplicateKeyError{Position: startPosition.advance(data[:start]), Path: c.path.child(key)}
+		}
+
+		i = skipSpace(data, i)
+		if i >= len(data) || data[i] != ':' {
+			return i, expectedError(data, i, "':' after object key")
+		}
+		i = skipSpace(data, i+1)
+		c.path = append(c.path, key)
+		i, err = c.value(i, depth)
+		c.path = c.path[:len(c.path)-1]
+		if err != nil {
+			return i, err
+		}
+
+		span := [2]int{start, i}
+		switch {
+		case !dup:
+			members[key] = span
+		case c.opts.DuplicateKeys == FirstWins:
+			c.dropped = append(c.dropped, span)
+		case c.opts.DuplicateKeys == LastWins:
+			c.dropped = append(c.dropped, prev)
+			members[key] = span
+		}
+
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+			continue
+		}
+		if i < len(data) && data[i] == '}' {
+			return i + 1, nil
+		}
+		return i, expectedError(data, i, "',' or '}' after object value")
+	}
+}
+
+func (c *checker) array(i int, depth int) (int, error) {
+	data := c.data
+	var err error
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return i + 1, nil
+	}
+	for n := 0; ; n++ {
+		c.path = append(c.path, n)
+		i, err = c.value(i, depth)
+		c.path = c.path[:len(c.path)-1]
+		if err != nil {
+			return i, err
+		}
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+			continue
+		}
+		if i < len(data) && data[i] == ']' {
+			return i + 1, nil
+		}
+		return i, expectedError(data, i, "',' or ']' after array element")
+	}
+}
@@ -0,0 +1,278 @@
+package jsonsearcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// MalformedPolicy decides what a line scanner does with a malformed document
+type MalformedPolicy int
+
+const (
+	// StopOnMalformed stops the scanning, Err returns the *LineError
+	StopOnMalformed MalformedPolicy = iota
+	// SkipMalformed skips the malformed lines silently, they are counted by Skipped
+	SkipMalformed
+	// ReportMalformed makes Scan stop at the malformed lines: Searcher returns nil and Malformed returns
+	// the *LineError, then the scanning continues with the next line
+	ReportMalformed
+)
+
+// ScannerOptions controls how the documents are read by NewScanner
+type ScannerOptions struct {
+	// Options are used to new the searcher of each document
+	Options Options
+	// Multiline allows a document to span multiple lines, such as a stream of indented documents.
+	// By default each line holds one or more complete documents, as NDJSON and JSON Lines do
+	Multiline bool
+	Malformed MalformedPolicy
+}
+
+// LineError is a malformed document found by a line scanner, Line is 1-based
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("malformed json at line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// NewScanner news a scanner which reads newline-delimited json documents(NDJSON or JSON Lines) from r.
+// Documents concatenated on one line, such as {"a":1}{"a":2}, are read one by one. The rest of a line
+// is skipped when a malformed document is found in it. The scanner is used like bufio.Scanner:
+//
+//	sc := jsonsearcher.NewScanner(r, jsonsearcher.ScannerOptions{})
+//	for sc.Scan() {
+//		s := sc.Searcher()
+//	}
+//	if err := sc.Err(); err != nil {
+//	}
+func NewScanner(r io.Reader, opts ScannerOptions) *lineScanner {
+	return &lineScanner{r: bufio.NewReader(r), opts: opts, line: 1}
+}
+
+type lineScanner struct {
+	r    *bufio.Reader
+	opts ScannerOptions
+
+	// buf[pos:] is the data read but not scanned yet, line is the line number of buf[pos]
+	buf  []byte
+	pos  int
+	line int
+	eof  bool
+
+	// A multiline document is validated once it's complete, instead of being rescanned on every line.
+	// tracked is the count of bytes after pos whose brackets are tracked, depth is the nesting depth
+	// there, and tried is the count of bytes after pos at the last validation
+	tracked  int
+	depth    int
+	inString bool
+	escaped  bool
+	tried    int
+
+	searcher  *searcher
+	docLine   int
+	malformed error
+	skipped   int
+	err       error
+}
+
+// Scan advances to the next document, which is available by Searcher. It returns false when the input
+// ends or an error stops the scanning
+func (sc *lineScanner) Scan() bool {
+	sc.searcher, sc.malformed = nil, nil
+	if sc.err != nil {
+		return false
+	}
+
+	for {
+		// Skip the whitespace between the documents
+		for sc.pos < len(sc.buf) && isSpace(sc.buf[sc.pos]) {
+			if sc.buf[sc.pos] == '\n' {
+				sc.line++
+			}
+			sc.pos++
+		}
+		data := sc.buf[sc.pos:]
+		if len(data) == 0 {
+			if sc.eof {
+				return false
+			}
+			if !sc.fill() {
+				return false
+			}
+			continue
+		}
+
+		limit := len(data)
+		if !sc.opts.Multiline {
+			nl := bytes.IndexByte(data, '\n')
+			if nl < 0 && !sc.eof {
+				if !sc.fill() {
+					return false
+				}
+				continue
+			}
+			if nl >= 0 {
+				limit = nl
+			}
+		}
+
+		if sc.opts.Multiline && !sc.eof && !sc.ready(data) {
+			// The document continues in the next lines
+			if !sc.fill() {
+				return false
+			}
+			continue
+		}
+		end, err := skipValue(data[:limit], 0)
+		if err != nil && sc.opts.Multiline && !sc.eof && err.(*scanError).off >= limit {
+			if !sc.fill() {
+				return false
+			}
+			continue
+		}
+		sc.resetTracking()
+		var s *searcher
+		if err == nil {
+			raw := data[:end]
+			if sc.opts.Options.Lazy {
+				// A lazy searcher keeps the bytes, which are overwritten by the next reads
+				raw = append([]byte(nil), raw...)
+			}
+			s, err = NewWithOptions(raw, sc.opts.Options)
+		}
+		if err != nil {
+			if sc.malformedLine(data, err) {
+				continue
+			}
+			return sc.opts.Malformed == ReportMalformed
+		}
+
+		sc.searcher, sc.docLine = s, sc.line
+		sc.line += bytes.Count(data[:end], []byte{'\n'})
+		sc.pos += end
+		return true
+	}
+}
+
+// ready reports whether the multiline document in data should be validated: its brackets are balanced,
+// or the data has doubled since the last validation, so that a malformed document is found early while
+// the total cost stays linear
+func (sc *lineScanner) ready(data []byte) bool {
+	if c := data[0]; c != '{' && c != '[' {
+		return true
+	}
+	closed := false
+	for ; sc.tracked < len(data) && !closed; sc.tracked++ {
+		c := data[sc.tracked]
+		switch {
+		case sc.escaped:
+			sc.escaped = false
+		case sc.inString:
+			if c == '\\' {
+				sc.escaped = true
+			} else if c == '"' {
+				sc.inString = false
+			}
+		case c == '"':
+			sc.inString = true
+		case c == '{' || c == '[':
+			sc.depth++
+		case c == '}' || c == ']':
+			sc.depth--
+			closed = sc.depth == 0
+		}
+	}
+	if closed || len(data) >= 2*sc.tried {
+		sc.tried = len(data)
+		return true
+	}
+	return false
+}
+
+func (sc *lineScanner) resetTracking() {
+	sc.tracked, sc.depth, sc.inString, sc.escaped, sc.tried = 0, 0, false, false, 0
+}
+
+// malformedLine drops the line where the malformed document starts, and reports whether the scanning
+// goes on to the next document silently
+func (sc *lineScanner) malformedLine(data []byte, err error) bool {
+	lineErr := &LineError{Line: sc.line, Err: err}
+	sc.resetTracking()
+	if nl := bytes.IndexByte(data, '\n'); nl >= 0 {
+		sc.pos += nl + 1
+		sc.line++
+	} else {
+		sc.pos += len(data)
+	}
+
+	switch sc.opts.Malformed {
+	case SkipMalformed:
+		sc.skipped++
+		return true
+	case ReportMalformed:
+		sc.malformed, sc.docLine = lineErr, lineErr.Line
+	default:
+		sc.err = lineErr
+	}
+	return false
+}
+
+// fill reads the next line into the buffer. It returns false if reading fails
+func (sc *lineScanner) fill() bool {
+	// Move the data not scanned to the front, so the buffer doesn't grow with the input. It's only moved
+	// when the data scanned is the bigger part, so a document of many lines is not copied on every line
+	if sc.pos > 0 && sc.pos >= len(sc.buf)-sc.pos {
+		n := copy(sc.buf, sc.buf[sc.pos:])
+		sc.buf, sc.pos = sc.buf[:n], 0
+	}
+	for {
+		chunk, err := sc.r.ReadSlice('\n')
+		sc.buf = append(sc.buf, chunk...)
+		switch err {
+		case nil:
+			return true
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			sc.eof = true
+			return true
+		default:
+			sc.err = err
+			return false
+		}
+	}
+}
+
+// Searcher returns the searcher of the current document, it's nil if the document is malformed
+func (sc *lineScanner) Searcher() *searcher {
+	return sc.searcher
+}
+
+// Line returns the line number where the current document starts, which is 1-based
+func (sc *lineScanner) Line() int {
+	return sc.docLine
+}
+
+// Malformed returns the *LineError of the current document with the ReportMalformed policy, or nil
+func (sc *lineScanner) Malformed() error {
+	return sc.malformed
+}
+
+// Skipped returns the count of the malformed lines skipped with the SkipMalformed policy
+func (sc *lineScanner) Skipped() int {
+	return sc.skipped
+}
+
+// Err returns the error which stopped the scanning, it's nil if the input ends normally
+func (sc *lineScanner) Err() error {
+	return sc.err
+}
@@ -0,0 +1,195 @@
+package jsonsearcher
+
+import (
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ArrayStrategy decides how two arrays are merged
+type ArrayStrategy int
+
+const (
+	// ArrayReplace replaces the array with the overlay's, as RFC 7396 does
+	ArrayReplace ArrayStrategy = iota
+	// ArrayAppend appends the overlay's elements to the array
+	ArrayAppend
+	// ArrayMergeByKey merges the objects of the two arrays which have the same value of MergeOptions.Key,
+	// the other elements of the overlay are appended
+	ArrayMergeByKey
+)
+
+// MergeOptions controls how the documents are merged
+type MergeOptions struct {
+	Arrays ArrayStrategy
+	// Key is the object member which identifies the array elements for ArrayMergeByKey
+	Key string
+}
+
+// Merge merges the overlays into base in order with RFC 7396 JSON Merge Patch semantics: objects are
+// merged recursively, a null member removes the key, and other values are replaced. The documents are
+// not modified, a new searcher is returned. Results of the new searcher can report which layer they
+// come from by Origin
+func Merge(base *searcher, overlays ...*searcher) *searcher {
+	return MergeWithOptions(MergeOptions{}, base, overlays...)
+}
+
+// MergeWithOptions is like Merge but merges arrays with the strategy of opts
+func MergeWithOptions(opts MergeOptions, base *searcher, overlays ...*searcher) *searcher {
+	m := &merger{opts: opts, dec: base.decoder(), origins: map[string]int{"": 0}, order: make(orderTable)}
+	root, _ := toJSONValue(base.tree(), base.decoder())
+	m.order.transfer(base.orderTable(), base.tree(), root)
+	for i, overlay := range overlays {
+		m.from = overlay.orderTable()
+		root = m.merge(root, overlay.tree(), Path{}, i+1)
+	}
+	return &searcher{root: root, useNumber: base.useNumber, order: m.order, origins: m.origins}
+}
+
+type merger struct {
+	opts MergeOptions
+	dec  jsoniter.API
+	// origins maps the JSON Pointer of the values to the index of the layer they come from,
+	// a value without an entry comes from the same layer as its parent
+	origins map[string]int
+	// order is the key order of the merged objects, from is the key order of the current overlay
+	order orderTable
+	from  orderTable
+}
+
+func (m *merger) merge(target, patch interface{}, path Path, layer int) interface{} {
+	switch pv := patch.(type) {
+	case map[string]interface{}:
+		tv, ok := target.(map[string]interface{})
+		if ok {
+			m.touch(path, tv, layer)
+		} else {
+			m.replace(path, target, layer)
+			tv = make(map[string]interface{})
+		}
+		// New keys follow the existing ones in the order of the overlay
+		ko := &keyOrder{obj: tv, keys: m.order.keys(tv)}
+		m.order[mapAddr(tv)] = ko
+		for _, k := range m.from.keys(pv) {
+			v := pv[k]
+			if v == nil {
+				m.remove(path.child(k), tv[k])
+				delete(tv, k)
+				continue
+			}
+			if _, ok := tv[k]; !ok {
+				ko.keys = append(ko.keys, k)
+			}
+			tv[k] = m.merge(tv[k], v, path.child(k), layer)
+		}
+		return tv
+	case []interface{}:
+		tv, ok := target.([]interface{})
+		if !ok || m.opts.Arrays == ArrayReplace {
+			break
+		}
+		m.touch(path, tv, layer)
+		for _, v := range pv {
+			if m.opts.Arrays == ArrayMergeByKey {
+				if i := m.findByKey(tv, v); i >= 0 {
+					tv[i] = m.merge(tv[i], v, path.child(i), layer)
+					continue
+				}
+			}
+			tv = append(tv, m.merge(nil, v, path.child(len(tv)), layer))
+		}
+		return tv
+	}
+
+	m.replace(path, target, layer)
+	v, _ := toJSONValue(patch, m.dec)
+	m.order.transfer(m.from, patch, v)
+	return v
+}
+
+// findByKey returns the index of the object in arr whose key member equals v's, or -1
+func (m *merger) findByKey(arr []interface{}, v interface{}) int {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	key, ok := obj[m.opts.Key]
+	if !ok {
+		return -1
+	}
+	for i, elem := range arr {
+		if eobj, ok := elem.(map[string]interface{}); ok {
+			if ekey, ok := eobj[m.opts.Key]; ok && jsonEqual(key, ekey) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// touch records that the container at path is modified by the layer. Its children keep their origins
+func (m *merger) touch(path Path, container interface{}, layer int) {
+	old := lookupOrigin(m.origins, formatPointer(path))
+	switch c := container.(type) {
+	case map[string]interface{}:
+		for k := range c {
+			if ptr := formatPointer(path.child(k)); !hasOrigin(m.origins, ptr) {
+				m.origins[ptr] = old
+			}
+		}
+	case []interface{}:
+		for i := range c {
+			if ptr := formatPointer(path.child(i)); !hasOrigin(m.origins, ptr) {
+				m.origins[ptr] = old
+			}
+		}
+	}
+	m.origins[formatPointer(path)] = layer
+}
+
+func hasOrigin(origins map[string]int, ptr string) bool {
+	_, ok := origins[ptr]
+	return ok
+}
+
+// lookupOrigin returns the origin of the value at ptr, which is inherited from the nearest recorded ancestor
+func lookupOrigin(origins map[string]int, ptr string) int {
+	for {
+		if layer, ok := origins[ptr]; ok || ptr == "" {
+			return layer
+		}
+		ptr = ptr[:strings.LastIndexByte(ptr, '/')]
+	}
+}
+
+// replace records that the value at path and all its children come from the layer, old is the value replaced
+func (m *merger) replace(path Path, old interface{}, layer int) {
+	m.remove(path, old)
+	m.origins[formatPointer(path)] = layer
+}
+
+// remove drops the records of the value old at path and its children. Only the values inside old can
+// have records, so old is walked instead of all the records
+func (m *merger) remove(path Path, old interface{}) {
+	delete(m.origins, formatPointer(path))
+	switch c := old.(type) {
+	case map[string]interface{}:
+		for k, v := range c {
+			m.remove(path.child(k), v)
+		}
+	case []interface{}:
+		for i, v := range c {
+			m.remove(path.child(i), v)
+		}
+	}
+}
+
+// Origin reports which layer the value comes from if the result is from a merged searcher: 0 is the base,
+// and i is the i-th overlay. A merged object reports the last layer which modified it.
+// Return false if the result is not from a merged searcher, or the document is modified after merging
+func (r *Result) Origin() (int, bool) {
+	if !r.exists || r.src == nil || r.src.origins == nil {
+		return 0, false
+	}
+	return lookupOrigin(r.src.origins, formatPointer(r.path)), true
+}
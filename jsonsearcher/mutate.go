@@ -0,0 +1,211 @@
+package jsonsearcher
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The mutation methods below are not safe for concurrent use, and results queried before a mutation
+// may share the modified objects and arrays.
+// Values are converted into json values(float64, string, bool, nil, []interface{} and map[string]interface{})
+// through the json encoding before stored, so any value json.Marshal accepts can be used, including *Result
+
+// Set sets the value at path. Intermediate objects and arrays are created where needed: a string
+// element creates an object, and an int element creates an array. Setting an index beyond the end
+// of an array pads it with null
+func (s *searcher) Set(path Path, value interface{}) error {
+	v, err := s.jsonValue(value)
+	if err != nil {
+		return err
+	}
+	return s.edit(path, true, func(old interface{}, exists bool) (interface{}, error) {
+		return v, nil
+	})
+}
+
+// jsonValue converts value into json values for s, the key order of a *Result is kept in the copy
+func (s *searcher) jsonValue(value interface{}) (interface{}, error) {
+	v, err := toJSONValue(value, s.decoder())
+	if err != nil {
+		return nil, err
+	}
+	r, ok := value.(*Result)
+	if !ok {
+		return v, nil
+	}
+	if t := s.orderTable(); t != nil {
+		if r.lazy != nil {
+			t.record(r.lazy.raw, 0, v)
+		} else {
+			t.transfer(r.src.orderTable(), r.val(), v)
+		}
+	}
+	return v, nil
+}
+
+// Delete deletes the value at path, the elements after it are shifted if it's in an array.
+// Return error when the value does not exist
+func (s *searcher) Delete(path Path) error {
+	if len(path) == 0 {
+		return errors.New("can not delete the root value")
+	}
+	parent, last := path[:len(path)-1], path[len(path)-1]
+	return s.edit(parent, false, func(old interface{}, exists bool) (interface{}, error) {
+		switch container := old.(type) {
+		case map[string]interface{}:
+			key, ok := last.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v is an object, expected string key but got %v", parent, last)
+			}
+			if _, ok := container[key]; !ok {
+				return nil, fmt.Errorf("%v does not exist", path)
+			}
+			delete(container, key)
+			return container, nil
+		case []interface{}:
+			index, ok := last.(int)
+			if !ok {
+				return nil, fmt.Errorf("%v is an array, expected int index but got %v", parent, last)
+			}
+			if index < 0 || index >= len(container) {
+				return nil, fmt.Errorf("%v does not exist", path)
+			}
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%v does not exist", path)
+		}
+	})
+}
+
+// Insert inserts the value into the array at path before the index, index can be the length of
+// the array. The array and its intermediate containers are created if they don't exist
+func (s *searcher) Insert(path Path, index int, value interface{}) error {
+	v, err := s.jsonValue(value)
+	if err != nil {
+		return err
+	}
+	return s.edit(path, true, func(old interface{}, exists bool) (interface{}, error) {
+		arr, ok := old.([]interface{})
+		if !ok && old != nil {
+			return nil, fmt.Errorf("%v is not an array", path)
+		}
+		if index < 0 || index > len(arr) {
+			return nil, fmt.Errorf("index %v is out of range of %v with length %v", index, path, len(arr))
+		}
+		arr = append(arr, nil)
+		copy(arr[index+1:], arr[index:])
+		arr[index] = v
+		return arr, nil
+	})
+}
+
+// Append appends the value to the array at path. The array and its intermediate containers are
+// created if they don't exist
+func (s *searcher) Append(path Path, value interface{}) error {
+	v, err := s.jsonValue(value)
+	if err != nil {
+		return err
+	}
+	return s.edit(path, true, func(old interface{}, exists bool) (interface{}, error) {
+		arr, ok := old.([]interface{})
+		if !ok && old != nil {
+			return nil, fmt.Errorf("%v is not an array", path)
+		}
+		return append(arr, v), nil
+	})
+}
+
+// Marshal returns the json encoding of the document
+func (s *searcher) Marshal() ([]byte, error) {
+	if err := s.decode(); err != nil {
+		return nil, err
+	}
+	return encodeValue(s.tree(), s.orderTable(), "", "", true)
+}
+
+// MarshalIndent is like Marshal but applies indent to format the output
+func (s *searcher) MarshalIndent(prefix, indent string) ([]byte, error) {
+	if err := s.decode(); err != nil {
+		return nil, err
+	}
+	return encodeValue(s.tree(), s.orderTable(), prefix, indent, true)
+}
+
+// edit replaces the value at path with the return value of fn. If create is set, the missing
+// containers on the path are created, otherwise they cause an error
+func (s *searcher) edit(path Path, create bool, fn func(old interface{}, exists bool) (interface{}, error)) error {
+	if err := s.decode(); err != nil {
+		return err
+	}
+	// The key order is recorded before the tree changes, the new keys are appended to it
+	s.orderTable()
+	root, err := s.editValue(s.tree(), true, Path{}, path, create, fn)
+	if err != nil {
+		return err
+	}
+	// The raw bytes of a lazy searcher and the origins of a merged searcher are stale after any mutation
+	s.lazy = false
+	s.data = nil
+	s.origins = nil
+	s.root = root
+	return nil
+}
+
+// editValue walks down rest from node at prefix, and returns the new node
+func (s *searcher) editValue(node interface{}, exists bool, prefix Path, rest Path, create bool,
+	fn func(old interface{}, exists bool) (interface{}, error)) (interface{}, error) {
+	if len(rest) == 0 {
+		return fn(node, exists)
+	}
+	if !exists && !create {
+		return nil, fmt.Errorf("%v does not exist", prefix)
+	}
+
+	switch key := rest[0].(type) {
+	case string:
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			if !create || node != nil {
+				return nil, fmt.Errorf("%v is not an object", prefix)
+			}
+			obj = make(map[string]interface{})
+		}
+		child, ok := obj[key]
+		child, err := s.editValue(child, ok, prefix.child(key), rest[1:], create, fn)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			s.order.appendKey(obj, key)
+		}
+		obj[key] = child
+		return obj, nil
+	case int:
+		arr, ok := node.([]interface{})
+		if !ok {
+			if !create || node != nil {
+				return nil, fmt.Errorf("%v is not an array", prefix)
+			}
+			arr = []interface{}{}
+		}
+		if key < 0 {
+			return nil, fmt.Errorf("negative index %v of %v", key, prefix)
+		}
+		var child interface{}
+		exists := key < len(arr)
+		if exists {
+			child = arr[key]
+		}
+		child, err := s.editValue(child, exists, prefix.child(key), rest[1:], create, fn)
+		if err != nil {
+			return nil, err
+		}
+		for len(arr) <= key {
+			arr = append(arr, nil)
+		}
+		arr[key] = child
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected type %T in path", key)
+	}
+}
@@ -0,0 +1,312 @@
+package jsonsearcher
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// numberJSON decodes numbers as json.Number, which keeps the original literal
+var numberJSON = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	UseNumber:              true,
+}.Froze()
+
+// NumberError is returned when a number can not be converted exactly
+type NumberError struct {
+	Path    Path
+	Literal string
+	// Target is the type converted into, such as int64
+	Target string
+	// Reason is "overflow", "underflow" or "fractional part". The exact value of a literal whose exponent
+	// is out of ±maxExponent is not computed, it's reported as overflow or underflow by the exponent sign
+	Reason string
+}
+
+func (e *NumberError) Error() string {
+	return fmt.Sprintf("jsonsearcher: can not convert %v = %v into %v: %v", e.Path, e.Literal, e.Target, e.Reason)
+}
+
+// numberLiteral returns the literal of a number result. The literal of a float64 is the shortest
+// representation which is decoded to the same float64
+func (r *Result) numberLiteral() (string, error) {
+	if err := r.check(TypeNumber); err != nil {
+		return "", err
+	}
+	switch v := r.val().(type) {
+	case stdjson.Number:
+		return string(v), nil
+	default:
+		return strconv.FormatFloat(v.(float64), 'g', -1, 64), nil
+	}
+}
+
+// BigInt returns the exact integer of the number. Return a *NumberError if the number has a fractional part
+func (r *Result) BigInt() (*big.Int, error) {
+	lit, err := r.numberLiteral()
+	if err != nil {
+		return nil, err
+	}
+	rat, reason := parseRat(lit)
+	if rat == nil {
+		return nil, &NumberError{Path: r.path, Literal: lit, Target: "big.Int", Reason: reason}
+	}
+	if !rat.IsInt() {
+		return nil, &NumberError{Path: r.path, Literal: lit, Target: "big.Int", Reason: "fractional part"}
+	}
+	return new(big.Int).Set(rat.Num()), nil
+}
+
+// BigFloat returns the number as a *big.Float, whose precision is large enough to hold the literal
+func (r *Result) BigFloat() (*big.Float, error) {
+	lit, err := r.numberLiteral()
+	if err != nil {
+		return nil, err
+	}
+	prec := uint(len(lit))*4 + 64
+	f, _, err := big.ParseFloat(lit, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, &NumberError{Path: r.path, Literal: lit, Target: "big.Float", Reason: err.Error()}
+	}
+	// The exponent of a big.Float is limited, a literal out of it is rounded to Inf or 0
+	if f.IsInf() || (f.Sign() == 0 && checkExponent(lit) != "") {
+		return nil, &NumberError{Path: r.path, Literal: lit, Target: "big.Float", Reason: checkExponent(lit)}
+	}
+	return f, nil
+}
+
+// Decimal returns the number as a decimal string without exponent, such as 1000 for 1e3.
+// The digits of the literal are kept as they are. Return a *NumberError if the exponent is out of
+// ±10000, since the string of a literal like 1e1000000000 would take gigabytes
+func (r *Result) Decimal() (string, error) {
+	lit, err := r.numberLiteral()
+	if err != nil {
+		return "", err
+	}
+	if reason := checkExponent(lit); reason != "" {
+		return "", &NumberError{Path: r.path, Literal: lit, Target: "decimal", Reason: reason}
+	}
+	return expandExponent(lit), nil
+}
+
+// exactInt64 converts the number into int64, reporting overflow and fractional part
+func (r *Result) exactInt64() (int64, error) {
+	if f, ok := r.val().(float64); ok {
+		if f != math.Trunc(f) {
+			return 0, &NumberError{Path: r.path, Literal: strconv.FormatFloat(f, 'g', -1, 64), Target: "int64", Reason: "fractional part"}
+		}
+		// float64(math.MaxInt64) is 2^63, which overflows
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, &NumberError{Path: r.path, Literal: strconv.FormatFloat(f, 'g', -1, 64), Target: "int64", Reason: "overflow"}
+		}
+		return int64(f), nil
+	}
+	i, err := r.BigInt()
+	if err != nil {
+		if ne, ok := err.(*NumberError); ok {
+			ne.Target = "int64"
+		}
+		return 0, err
+	}
+	if !i.IsInt64() {
+		return 0, &NumberError{Path: r.path, Literal: string(r.val().(stdjson.Number)), Target: "int64", Reason: "overflow"}
+	}
+	return i.Int64(), nil
+}
+
+// exactUint64 converts the number into uint64, reporting overflow and fractional part
+func (r *Result) exactUint64() (uint64, error) {
+	if f, ok := r.val().(float64); ok {
+		if f != math.Trunc(f) {
+			return 0, &NumberError{Path: r.path, Literal: strconv.FormatFloat(f, 'g', -1, 64), Target: "uint64", Reason: "fractional part"}
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			return 0, &NumberError{Path: r.path, Literal: strconv.FormatFloat(f, 'g', -1, 64), Target: "uint64", Reason: "overflow"}
+		}
+		return uint64(f), nil
+	}
+	i, err := r.BigInt()
+	if err != nil {
+		if ne, ok := err.(*NumberError); ok {
+			ne.Target = "uint64"
+		}
+		return 0, err
+	}
+	if !i.IsUint64() {
+		return 0, &NumberError{Path: r.path, Literal: string(r.val().(stdjson.Number)), Target: "uint64", Reason: "overflow"}
+	}
+	return i.Uint64(), nil
+}
+
+// maxExponent is the max absolute exponent of a number literal whose exact value is computed
+const maxExponent = 10000
+
+// checkExponent returns "overflow" or "underflow" if the exponent of a number literal is out of
+// ±maxExponent, or "" if the exact value of the literal can be computed. Zero can always be computed
+func checkExponent(lit string) string {
+	e := strings.IndexAny(lit, "eE")
+	if e < 0 || strings.Trim(lit[:e], "-0.") == "" {
+		return ""
+	}
+	exp, err := strconv.Atoi(lit[e+1:])
+	if err == nil && exp >= -maxExponent && exp <= maxExponent {
+		return ""
+	}
+	if lit[e+1] == '-' {
+		return "underflow"
+	}
+	return "overflow"
+}
+
+// parseRat returns the exact value of a number literal, or nil and the reason of checkExponent
+func parseRat(lit string) (*big.Rat, string) {
+	if reason := checkExponent(lit); reason != "" {
+		return nil, reason
+	}
+	x, ok := new(big.Rat).SetString(expandExponent(lit))
+	if !ok {
+		return nil, "invalid number"
+	}
+	return x, ""
+}
+
+// expandExponent rewrites a number literal like 1.5e3 into 1500, the exponent must be checked by
+// checkExponent
+func expandExponent(lit string) string {
+	e := strings.IndexAny(lit, "eE")
+	if e < 0 {
+		return lit
+	}
+	mantissa := lit[:e]
+	sign := ""
+	if mantissa[0] == '-' {
+		sign, mantissa = "-", mantissa[1:]
+	}
+	if strings.Trim(mantissa, "0.") == "" {
+		return sign + "0"
+	}
+	exp, _ := strconv.Atoi(lit[e+1:])
+	point := strings.IndexByte(mantissa, '.')
+	if point < 0 {
+		point = len(mantissa)
+	}
+	digits := mantissa[:point]
+	if point < len(mantissa) {
+		digits += mantissa[point+1:]
+	}
+
+	point += exp
+	switch {
+	case point <= 0:
+		digits = "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		digits += strings.Repeat("0", point-len(digits))
+	default:
+		digits = digits[:point] + "." + digits[point:]
+	}
+	// Strip the leading zeros of the integer part, such as 00.5
+	for len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		digits = digits[1:]
+	}
+	return sign + digits
+}
+
+// toRat converts a json number(float64 or json.Number) into *big.Rat
+func toRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(n), true
+	case stdjson.Number:
+		x, _ := parseRat(string(n))
+		return x, x != nil
+	}
+	return nil, false
+}
+
+// toFloat converts a json number(float64 or json.Number) into float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case stdjson.Number:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// compareNumbers compares two json numbers, ok is false if any of them is not a number. Two json.Number
+// are compared exactly, a float64 is compared as float64 because it is rounded already
+func compareNumbers(a, b interface{}) (cmp int, ok bool) {
+	_, fa := a.(float64)
+	_, fb := b.(float64)
+	if fa || fb {
+		x, ok := toFloat(a)
+		if !ok {
+			return 0, false
+		}
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	ra, ok := toRat(a)
+	if !ok {
+		return 0, false
+	}
+	rb, ok := toRat(b)
+	if !ok {
+		return 0, false
+	}
+	return ra.Cmp(rb), true
+}
+
+// jsonEqual reports whether two json values are equal, numbers are compared by their values
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, x := range av {
+			y, ok := bv[k]
+			if !ok || !jsonEqual(x, y) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case float64, stdjson.Number:
+		cmp, ok := compareNumbers(a, b)
+		return ok && cmp == 0
+	}
+	return a == b
+}
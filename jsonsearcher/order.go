@@ -0,0 +1,256 @@
+package jsonsearcher
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+)
+
+// keyOrder is the document order of the keys of an object. obj is kept so that the address of
+// the map can not be reused by another map
+type keyOrder struct {
+	obj  map[string]interface{}
+	keys []string
+}
+
+// orderTable maps the address of the decoded objects to the order of their keys
+type orderTable map[uintptr]*keyOrder
+
+func mapAddr(m map[string]interface{}) uintptr {
+	return reflect.ValueOf(m).Pointer()
+}
+
+// record records the key order of the objects in v, which is decoded from the raw value starting at i.
+// The raw value is scanned once, and the offset just after it is returned
+func (t orderTable) record(data []byte, i int, v interface{}) int {
+	switch data[i] {
+	case '{':
+		// A value which doesn't match the raw one is from an earlier duplicate key, the later one
+		// records the objects again
+		x, _ := v.(map[string]interface{})
+		// The keys of the map are sorted into document order in place, so they are not allocated again.
+		// A duplicate key is not found in the unsorted part, it keeps its first position
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		// index finds the keys of a big object, a small one is searched linearly
+		var index map[string]int
+		if len(keys) > 32 {
+			index = make(map[string]int, len(keys))
+			for j, k := range keys {
+				index[k] = j
+			}
+		}
+		sorted := 0
+		i = skipSpace(data, i+1)
+		for data[i] == '"' {
+			keyEnd, _ := skipString(data, i)
+			raw := data[i+1 : keyEnd-1]
+			var elem interface{}
+			if x != nil {
+				if bytes.IndexByte(raw, '\\') >= 0 {
+					raw = []byte(decodeKey(raw))
+				}
+				j := sorted
+				if index != nil {
+					if n, ok := index[string(raw)]; ok && n >= sorted {
+						j = n
+					} else {
+						j = len(keys)
+					}
+				} else {
+					for j < len(keys) && keys[j] != string(raw) {
+						j++
+					}
+				}
+				if j < len(keys) {
+					if index != nil {
+						index[keys[j]], index[keys[sorted]] = sorted, j
+					}
+					keys[sorted], keys[j] = keys[j], keys[sorted]
+					sorted++
+				}
+				elem = x[string(raw)]
+			}
+			i = skipSpace(data, skipSpace(data, keyEnd)+1)
+			i = skipSpace(data, t.record(data, i, elem))
+			if data[i] == ',' {
+				i = skipSpace(data, i+1)
+			}
+		}
+		// The order of an object with less than 2 keys is trivial
+		if len(x) > 1 {
+			t[mapAddr(x)] = &keyOrder{obj: x, keys: keys}
+		}
+		return i + 1
+	case '[':
+		x, _ := v.([]interface{})
+		i = skipSpace(data, i+1)
+		for n := 0; data[i] != ']'; n++ {
+			var elem interface{}
+			if n < len(x) {
+				elem = x[n]
+			}
+			i = skipSpace(data, t.record(data, i, elem))
+			if data[i] == ',' {
+				i = skipSpace(data, i+1)
+			}
+		}
+		return i + 1
+	default:
+		end, _ := skipValue(data, i)
+		return end
+	}
+}
+
+// transfer records the key order of the objects in src for the same objects in dst, which is a copy of src
+func (t orderTable) transfer(from orderTable, src, dst interface{}) {
+	switch x := src.(type) {
+	case map[string]interface{}:
+		y, ok := dst.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if ko, ok := from[mapAddr(x)]; ok {
+			t[mapAddr(y)] = &keyOrder{obj: y, keys: ko.keys}
+		}
+		for k, v := range x {
+			t.transfer(from, v, y[k])
+		}
+	case []interface{}:
+		y, ok := dst.([]interface{})
+		if !ok {
+			return
+		}
+		for n := 0; n < len(x) && n < len(y); n++ {
+			t.transfer(from, x[n], y[n])
+		}
+	}
+}
+
+// keys returns the keys of m in document order. Keys added after decoding follow in sorted order
+func (t orderTable) keys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	if ko, ok := t[mapAddr(m)]; ok {
+		for _, k := range ko.keys {
+			if _, ok := m[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == len(m) {
+			return keys
+		}
+	}
+
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k] = true
+	}
+	extra := make([]string, 0, len(m)-len(keys))
+	for k := range m {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	return append(keys, extra...)
+}
+
+// appendKey records that key is added to m after its existing keys
+func (t orderTable) appendKey(m map[string]interface{}, key string) {
+	if t == nil {
+		return
+	}
+	t[mapAddr(m)] = &keyOrder{obj: m, keys: append(t.keys(m), key)}
+}
+
+// Keys returns the keys of the object in document order, or nil if the result is not an object. The keys
+// are sorted if the order is unknown, which is the case of an eager searcher without KeepOrder and KeepRaw
+func (r *Result) Keys() []string {
+	if !r.exists || r.resType != TypeObject {
+		return nil
+	}
+	if r.lazy != nil {
+		var keys []string
+		seen := make(map[string]bool)
+		forEachMember(r.lazy.raw, 0, func(raw []byte, start int) bool {
+			if k := decodeKey(raw); !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+			return true
+		})
+		return keys
+	}
+	return r.src.orderTable().keys(r.value.(map[string]interface{}))
+}
+
+// ForEach calls fn for each member of an object or each element of an array in document order, until fn
+// returns false. key is a string result of the member key, or a number result of the element index.
+// Nothing happens if the result is not an object or an array
+func (r *Result) ForEach(fn func(key Result, value *Result) bool) {
+	if !r.exists {
+		return
+	}
+	switch r.resType {
+	case TypeObject:
+		if r.lazy != nil {
+			for _, k := range r.Keys() {
+				if !fn(r.key(k), r.Query(k)) {
+					return
+				}
+			}
+			return
+		}
+		obj := r.value.(map[string]interface{})
+		for _, k := range r.Keys() {
+			if !fn(r.key(k), r.child(obj[k], k)) {
+				return
+			}
+		}
+	case TypeArray:
+		if r.lazy != nil {
+			n := 0
+			forEachElement(r.lazy.raw, 0, func(start int) bool {
+				end, _ := skipValue(r.lazy.raw, start)
+				value := newRawResult(r.lazy.raw[start:end], r.path.child(n))
+				value.lazy.off = r.lazy.off + start
+				value.src = r.src
+				n++
+				return fn(r.key(n-1), value)
+			})
+			return
+		}
+		for i, v := range r.value.([]interface{}) {
+			if !fn(r.key(i), r.child(v, i)) {
+				return
+			}
+		}
+	}
+}
+
+// key returns the result of a member key or an element index
+func (r *Result) key(k interface{}) Result {
+	path := r.path.child(k)
+	if i, ok := k.(int); ok {
+		k = float64(i)
+	}
+	return Result{resType: typeOf(k), exists: true, path: path, value: k}
+}
+
+// orderTable returns the key orders of the searcher, which are recorded from the kept data on first use.
+// The tree of a lazy searcher is decoded if needed
+func (s *searcher) orderTable() orderTable {
+	if s == nil {
+		return nil
+	}
+	root := s.tree()
+	s.orderOnce.Do(func() {
+		if s.order == nil && s.data != nil {
+			s.order = make(orderTable)
+			s.order.record(s.data, skipSpace(s.data, 0), root)
+		}
+	})
+	return s.order
+}
@@ -0,0 +1,282 @@
+package jsonsearcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// PatchError is returned when a JSON Patch can not be applied, Index is the index of the failed operation
+type PatchError struct {
+	Index int
+	Op    string
+	Path  string
+	Msg   string
+}
+
+func (e *PatchError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("invalid json patch: %s", e.Msg)
+	}
+	return fmt.Sprintf("json patch operation %d(%s %s) failed: %s", e.Index, e.Op, e.Path, e.Msg)
+}
+
+// ApplyPatch applies a RFC 6902 JSON Patch, the operations add, remove, replace, move, copy and test
+// are supported. The patch is applied atomically: if any operation fails, the document is not modified
+func (s *searcher) ApplyPatch(patchJSON []byte) error {
+	var patch interface{}
+	if err := s.decoder().Unmarshal(patchJSON, &patch); err != nil {
+		return &PatchError{Index: -1, Msg: err.Error()}
+	}
+	ops, ok := patch.([]interface{})
+	if !ok {
+		return &PatchError{Index: -1, Msg: "the patch must be an array of operations"}
+	}
+
+	if err := s.decode(); err != nil {
+		return err
+	}
+	// The operations are applied to a copy, which replaces the document when all of them succeed
+	root, err := toJSONValue(s.tree(), s.decoder())
+	if err != nil {
+		return err
+	}
+	work := &searcher{root: root, useNumber: s.useNumber, order: make(orderTable)}
+	work.order.transfer(s.orderTable(), s.tree(), root)
+	for i, op := range ops {
+		if err := work.applyOperation(op); err != nil {
+			pe := &PatchError{Index: i, Msg: err.Error()}
+			if obj, ok := op.(map[string]interface{}); ok {
+				pe.Op, _ = obj["op"].(string)
+				pe.Path, _ = obj["path"].(string)
+			}
+			return pe
+		}
+	}
+
+	s.lazy = false
+	s.data = nil
+	s.origins = nil
+	s.root = work.root
+	s.order = work.order
+	return nil
+}
+
+func (s *searcher) applyOperation(op interface{}) error {
+	obj, ok := op.(map[string]interface{})
+	if !ok {
+		return errors.New("the operation must be an object")
+	}
+	name, ok := obj["op"].(string)
+	if !ok {
+		return errors.New(`missing "op" member`)
+	}
+	ptr, ok := obj["path"].(string)
+	if !ok {
+		return errors.New(`missing "path" member`)
+	}
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return err
+	}
+	value, hasValue := obj["value"]
+
+	var from []string
+	if name == "move" || name == "copy" {
+		fromPtr, ok := obj["from"].(string)
+		if !ok {
+			return errors.New(`missing "from" member`)
+		}
+		if from, err = parsePointer(fromPtr); err != nil {
+			return err
+		}
+	}
+
+	switch name {
+	case "add":
+		if !hasValue {
+			return errors.New(`missing "value" member`)
+		}
+		return s.patchAdd(tokens, value)
+	case "remove":
+		_, err := s.patchRemove(tokens)
+		return err
+	case "replace":
+		if !hasValue {
+			return errors.New(`missing "value" member`)
+		}
+		if _, err := s.patchRemove(tokens); err != nil {
+			return err
+		}
+		return s.patchAdd(tokens, value)
+	case "move":
+		if len(from) < len(tokens) && reflect.DeepEqual(from, tokens[:len(from)]) {
+			return errors.New("can not move a value into one of its children")
+		}
+		v, err := s.patchRemove(from)
+		if err != nil {
+			return err
+		}
+		return s.patchAdd(tokens, v)
+	case "copy":
+		v, err := s.patchGet(from)
+		if err != nil {
+			return err
+		}
+		if v, err = toJSONValue(v, s.decoder()); err != nil {
+			return err
+		}
+		return s.patchAdd(tokens, v)
+	case "test":
+		if !hasValue {
+			return errors.New(`missing "value" member`)
+		}
+		v, err := s.patchGet(tokens)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(v, value) {
+			return errors.New("test failed, the values are not equal")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+func (s *searcher) patchGet(tokens []string) (interface{}, error) {
+	path, err := resolvePointer(s.root, tokens)
+	if err != nil {
+		return nil, err
+	}
+	r := s.Query(path...)
+	if !r.Exists() {
+		return nil, fmt.Errorf("%v does not exist", formatPointer(path))
+	}
+	return r.val(), nil
+}
+
+// patchAdd adds the value following the add operation: the target is replaced if it's an object
+// member, or inserted if it's an array element. The parent of the target must exist
+func (s *searcher) patchAdd(tokens []string, value interface{}) error {
+	if len(tokens) == 0 {
+		s.root = value
+		return nil
+	}
+	path, err := resolvePointer(s.root, tokens)
+	if err != nil {
+		return err
+	}
+	parent, last := path[:len(path)-1], path[len(path)-1]
+	switch container := s.Query(parent...).val().(type) {
+	case map[string]interface{}:
+		container[tokens[len(tokens)-1]] = value
+		return nil
+	case []interface{}:
+		index, ok := last.(int)
+		if !ok {
+			if last != "-" {
+				return fmt.Errorf("invalid array index %q", last)
+			}
+			index = len(container)
+		}
+		if index > len(container) {
+			return fmt.Errorf("index %d is out of range of %v", index, formatPointer(parent))
+		}
+		return s.Insert(parent, index, value)
+	default:
+		return fmt.Errorf("%v is not an object or an array", formatPointer(parent))
+	}
+}
+
+// patchRemove removes the target and returns its value
+func (s *searcher) patchRemove(tokens []string) (interface{}, error) {
+	v, err := s.patchGet(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		s.root = nil
+		return v, nil
+	}
+	path, _ := resolvePointer(s.root, tokens)
+	return v, s.Delete(path)
+}
+
+// GeneratePatch generates a RFC 6902 JSON Patch which transforms the document of a into the document of b
+func GeneratePatch(a, b *searcher) ([]byte, error) {
+	for _, s := range []*searcher{a, b} {
+		if err := s.decode(); err != nil {
+			return nil, err
+		}
+	}
+	ops := []interface{}{}
+	order := b.orderTable()
+	ops = generatePatch(ops, Path{}, a.tree(), b.tree(), a.orderTable(), order)
+	// The values of the operations are from b
+	return encodeValue(ops, order, "", "", true)
+}
+
+// generatePatch appends the operations transforming a into b, the members are compared in the document
+// order of a, then the ones only in b follow in the document order of b
+func generatePatch(ops []interface{}, path Path, a, b interface{}, orderA, orderB orderTable) []interface{} {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := orderA.keys(av)
+		for _, k := range orderB.keys(bv) {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		for _, k := range keys {
+			x, inA := av[k]
+			y, inB := bv[k]
+			switch {
+			case !inB:
+				ops = append(ops, patchOp("remove", path.child(k), nil, false))
+			case !inA:
+				ops = append(ops, patchOp("add", path.child(k), y, true))
+			default:
+				ops = generatePatch(ops, path.child(k), x, y, orderA, orderB)
+			}
+		}
+		return ops
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) < n {
+			n = len(bv)
+		}
+		for i := 0; i < n; i++ {
+			ops = generatePatch(ops, path.child(i), av[i], bv[i], orderA, orderB)
+		}
+		// Remove from the end so that the indexes stay valid
+		for i := len(av) - 1; i >= len(bv); i-- {
+			ops = append(ops, patchOp("remove", path.child(i), nil, false))
+		}
+		for i := len(av); i < len(bv); i++ {
+			ops = append(ops, patchOp("add", path.child(i), bv[i], true))
+		}
+		return ops
+	}
+
+	if !jsonEqual(a, b) {
+		ops = append(ops, patchOp("replace", path, b, true))
+	}
+	return ops
+}
+
+func patchOp(op string, path Path, value interface{}, hasValue bool) map[string]interface{} {
+	m := map[string]interface{}{"op": op, "path": formatPointer(path)}
+	if hasValue {
+		m["value"] = value
+	}
+	return m
+}
@@ -0,0 +1,563 @@
+package jsonsearcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PathError is returned when a JSONPath expression can not be parsed
+type PathError struct {
+	Path   string
+	Offset int
+	Msg    string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("invalid json path %q at offset %d: %s", e.Path, e.Offset, e.Msg)
+}
+
+// Path is the location of a value in the document, each element is a string(object key) or an int(array index).
+// A Path can be passed to Query directly: s.Query(path...)
+type Path []interface{}
+
+// String renders the path as a JSONPath expression, such as $.friends[1].email
+func (p Path) String() string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, elem := range p {
+		switch e := elem.(type) {
+		case int:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(e))
+			sb.WriteByte(']')
+		case string:
+			if isPlainName(e) {
+				sb.WriteByte('.')
+				sb.WriteString(e)
+			} else {
+				sb.WriteString("['")
+				sb.WriteString(strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(e))
+				sb.WriteString("']")
+			}
+		default:
+			sb.WriteString(fmt.Sprintf("[%v]", e))
+		}
+	}
+	return sb.String()
+}
+
+func isPlainName(name string) bool {
+	if name == "" || name == "*" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '.', '[', '\'', '"', '\\', '$', '@', '?', ':':
+			return false
+		default:
+			if isPathSpace(c) || isOperatorChar(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type segmentKind int
+
+const (
+	segName segmentKind = iota
+	segIndex
+	segWildcard
+	segFilter
+)
+
+// segment is a single step of a parsed JSONPath expression
+type segment struct {
+	kind  segmentKind
+	name  string
+	index int
+	// filter is the predicate of segFilter, which selects the children matching it
+	filter filterExpr
+	// recursive is set for the descendant segments(..), the selector is applied to the value and all its descendants
+	recursive bool
+}
+
+// singular reports whether the segments select at most one value
+func singular(segs []segment) bool {
+	for _, seg := range segs {
+		if seg.recursive || (seg.kind != segName && seg.kind != segIndex) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryPath queries specific json field by a JSONPath expression, such as $.friends[1].email or
+// $['key with spaces'].name, a negative index counts from the end of the array like $.friends[-1].
+// Return error when the expression is invalid. If the expression may match multiple values, the
+// first match is returned
+func (s *searcher) QueryPath(path string) (*Result, error) {
+	q, err := Compile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decode(); err != nil {
+		return nil, err
+	}
+	return q.First(s), nil
+}
+
+// QueryAll queries all the json fields matched by a JSONPath expression. Wildcards(friends[*].name) and
+// recursive descent($..email) are supported, each Result carries the concrete path it was found at.
+// Return error when the expression is invalid
+func (s *searcher) QueryAll(path string) ([]*Result, error) {
+	q, err := Compile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decode(); err != nil {
+		return nil, err
+	}
+	return q.All(s), nil
+}
+
+// Query is a compiled JSONPath expression. It's parsed and validated once by Compile, then it can be run
+// against any number of searchers. A Query is immutable, so it's safe to be used by multiple goroutines
+type Query struct {
+	src  string
+	segs []segment
+	// args are the keys and indexes of a singular expression, which is run by searcher.Query.
+	// args is nil if the expression may match multiple values
+	args Path
+}
+
+// Compile parses a JSONPath expression into a Query. Return error when the expression is invalid
+func Compile(path string) (*Query, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{src: path, segs: segs}
+	if singular(segs) {
+		q.args = make(Path, 0, len(segs))
+		for _, seg := range segs {
+			switch {
+			case seg.kind == segName:
+				q.args = append(q.args, seg.name)
+			case seg.index >= 0:
+				q.args = append(q.args, seg.index)
+			default:
+				// A negative index depends on the length of the array, so it's evaluated by the segments
+				q.args = nil
+				return q, nil
+			}
+		}
+	}
+	return q, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid
+func MustCompile(path string) *Query {
+	q, err := Compile(path)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the source expression of the query
+func (q *Query) String() string {
+	return q.src
+}
+
+// First runs the query against the document of s, and returns the first match like QueryPath
+func (q *Query) First(s *searcher) *Result {
+	if q.args != nil {
+		return s.Query(q.args...)
+	}
+	results := s.evaluate(q.segs)
+	if len(results) == 0 {
+		return &Result{src: s}
+	}
+	return results[0]
+}
+
+// All runs the query against the document of s, and returns all the matches like QueryAll
+func (q *Query) All(s *searcher) []*Result {
+	return s.evaluate(q.segs)
+}
+
+func (s *searcher) evaluate(segs []segment) []*Result {
+	root := s.tree()
+	return evaluateFrom(s.result(root, Path{}), segs, root)
+}
+
+// evaluateFrom applies the segments to start, root is the value which $ refers to in filters
+func evaluateFrom(start *Result, segs []segment, root interface{}) []*Result {
+	nodes := []*Result{start}
+	for _, seg := range segs {
+		if seg.recursive {
+			var all []*Result
+			for _, n := range nodes {
+				all = appendDescendants(all, n)
+			}
+			nodes = all
+		}
+		var next []*Result
+		for _, n := range nodes {
+			next = seg.apply(next, n, root)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply appends the children of n selected by the segment to dst
+func (seg *segment) apply(dst []*Result, n *Result, root interface{}) []*Result {
+	switch seg.kind {
+	case segName:
+		if obj, ok := n.val().(map[string]interface{}); ok {
+			if v, ok := obj[seg.name]; ok {
+				dst = append(dst, n.child(v, seg.name))
+			}
+		}
+	case segIndex:
+		if arr, ok := n.val().([]interface{}); ok {
+			// A negative index counts from the end of the array, -1 is the last element
+			i := seg.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				dst = append(dst, n.child(arr[i], i))
+			}
+		}
+	case segWildcard:
+		dst = appendChildren(dst, n)
+	case segFilter:
+		for _, c := range appendChildren(nil, n) {
+			if seg.filter.match(c, root) {
+				dst = append(dst, c)
+			}
+		}
+	}
+	return dst
+}
+
+// appendChildren appends the members of an object(ordered by key) or the elements of an array to dst
+func appendChildren(dst []*Result, n *Result) []*Result {
+	switch v := n.val().(type) {
+	case map[string]interface{}:
+		for _, k := range n.src.orderTable().keys(v) {
+			dst = append(dst, n.child(v[k], k))
+		}
+	case []interface{}:
+		for i, elem := range v {
+			dst = append(dst, n.child(elem, i))
+		}
+	}
+	return dst
+}
+
+// appendDescendants appends n and all its descendants to dst in pre-order
+func appendDescendants(dst []*Result, n *Result) []*Result {
+	dst = append(dst, n)
+	for _, c := range appendChildren(nil, n) {
+		dst = appendDescendants(dst, c)
+	}
+	return dst
+}
+
+// child returns a new path with elem appended, p itself is never modified
+func (p Path) child(elem interface{}) Path {
+	np := make(Path, len(p)+1)
+	copy(np, p)
+	np[len(p)] = elem
+	return np
+}
+
+// hasPrefix reports whether prefix is the start of p
+func (p Path) hasPrefix(prefix Path) bool {
+	if len(p) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if p[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type pathParser struct {
+	src string
+	pos int
+}
+
+func parsePath(path string) ([]segment, error) {
+	p := &pathParser{src: path}
+	return p.parse()
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return &PathError{Path: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *pathParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *pathParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *pathParser) skipSpace() {
+	for !p.eof() && isPathSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *pathParser) parse() ([]segment, error) {
+	segs := []segment{}
+
+	p.skipSpace()
+	if p.peek() == '$' {
+		p.pos++
+	} else if !p.eof() && p.peek() != '.' && p.peek() != '[' {
+		// A relative path like friends[0].name starts with a bare member name
+		seg, err := p.parseDotName()
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return segs, nil
+		}
+		if c := p.peek(); c != '.' && c != '[' {
+			return nil, p.errorf("unexpected character %q", c)
+		}
+		seg, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+}
+
+// parseSegment parses a segment starting with . or [
+func (p *pathParser) parseSegment() (segment, error) {
+	if p.peek() == '[' {
+		p.pos++
+		return p.parseBracket()
+	}
+
+	p.pos++
+	recursive := false
+	if p.peek() == '.' {
+		p.pos++
+		recursive = true
+	}
+	var seg segment
+	var err error
+	if recursive && p.peek() == '[' {
+		p.pos++
+		seg, err = p.parseBracket()
+	} else {
+		seg, err = p.parseDotName()
+	}
+	if err != nil {
+		return segment{}, err
+	}
+	seg.recursive = recursive
+	return seg, nil
+}
+
+// parseDotName parses the member name following a dot
+func (p *pathParser) parseDotName() (segment, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c == '.' || c == '[' || isPathSpace(c) || isOperatorChar(c) {
+			break
+		}
+		if c == '\'' || c == '"' {
+			return segment{}, p.errorf("unexpected character %q in member name", c)
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return segment{}, p.errorf("expected member name")
+	}
+	if p.src[start:p.pos] == "*" {
+		return segment{kind: segWildcard}, nil
+	}
+	return segment{kind: segName, name: p.src[start:p.pos]}, nil
+}
+
+// parseBracket parses the selector between [ and ], the leading [ is already consumed
+func (p *pathParser) parseBracket() (segment, error) {
+	p.skipSpace()
+	var seg segment
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseQuoted()
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{kind: segName, name: name}
+	case c == '-' || (c >= '0' && c <= '9'):
+		index, err := p.parseInt()
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{kind: segIndex, index: index}
+	case c == '*':
+		p.pos++
+		seg = segment{kind: segWildcard}
+	case c == '?':
+		p.pos++
+		expr, err := p.parseFilter()
+		if err != nil {
+			return segment{}, err
+		}
+		seg = segment{kind: segFilter, filter: expr}
+	case c == 0:
+		return segment{}, p.errorf("unclosed bracket")
+	default:
+		return segment{}, p.errorf("unexpected character %q in brackets", c)
+	}
+
+	p.skipSpace()
+	if p.peek() != ']' {
+		if p.eof() {
+			return segment{}, p.errorf("unclosed bracket")
+		}
+		return segment{}, p.errorf("expected ']' but found %q", p.peek())
+	}
+	p.pos++
+	return seg, nil
+}
+
+func (p *pathParser) parseInt() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for !p.eof() && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	lit := p.src[start:p.pos]
+	n, err := strconv.Atoi(lit)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("invalid index %q", lit)
+	}
+	return n, nil
+}
+
+// parseQuoted parses a single or double quoted string, the escapes of JSON strings are supported
+func (p *pathParser) parseQuoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			p.pos++
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case '\'', '"', '\\', '/':
+				sb.WriteByte(e)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				r, err := p.parseUnicodeEscape()
+				if err != nil {
+					return "", err
+				}
+				sb.WriteRune(r)
+			default:
+				p.pos--
+				return "", p.errorf("invalid escape character %q", e)
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseUnicodeEscape parses the XXXX of \uXXXX, surrogate pairs are combined
+func (p *pathParser) parseUnicodeEscape() (rune, error) {
+	r, err := p.parseHex4()
+	if err != nil {
+		return 0, err
+	}
+	if r >= 0xD800 && r < 0xDC00 && strings.HasPrefix(p.src[p.pos:], "\\u") {
+		save := p.pos
+		p.pos += 2
+		r2, err := p.parseHex4()
+		if err == nil && r2 >= 0xDC00 && r2 < 0xE000 {
+			return (r-0xD800)<<10 + (r2 - 0xDC00) + 0x10000, nil
+		}
+		p.pos = save
+	}
+	if r >= 0xD800 && r < 0xE000 {
+		return utf8.RuneError, nil
+	}
+	return r, nil
+}
+
+func (p *pathParser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.src) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	p.pos += 4
+	return rune(n), nil
+}
+
+// isOperatorChar reports whether c terminates a member name, so that @.age>=18 is parsed as expected
+func isOperatorChar(c byte) bool {
+	switch c {
+	case ']', '(', ')', '!', '=', '<', '>', '&', '|', ',', '~':
+		return true
+	}
+	return false
+}
+
+func isPathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
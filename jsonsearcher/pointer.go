@@ -0,0 +1,131 @@
+package jsonsearcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits a RFC 6901 JSON Pointer into its unescaped reference tokens
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return []string{}, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid json pointer %q: must start with '/'", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 >= len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid json pointer %q: '~' must be followed by '0' or '1'", ptr)
+			}
+		}
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// formatPointer renders the path as a RFC 6901 JSON Pointer
+func formatPointer(path Path) string {
+	var sb strings.Builder
+	for _, elem := range path {
+		sb.WriteByte('/')
+		switch e := elem.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(e))
+		case string:
+			sb.WriteString(strings.Replace(strings.Replace(e, "~", "~0", -1), "/", "~1", -1))
+		default:
+			sb.WriteString(fmt.Sprint(e))
+		}
+	}
+	return sb.String()
+}
+
+// arrayIndex converts a reference token into an array index, leading zeros are not allowed
+func arrayIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return 0, false
+		}
+	}
+	index, err := strconv.Atoi(token)
+	return index, err == nil
+}
+
+// resolvePointer converts the reference tokens into a Path against the document. A token is an array
+// index if the value it applies to is an array, otherwise it's an object key. The values referenced by
+// the tokens except the last one must exist. The last token of an array is returned as a string if it's
+// not a valid index, such as "-"
+func resolvePointer(root interface{}, tokens []string) (Path, error) {
+	path := make(Path, 0, len(tokens))
+	v := root
+	for i, token := range tokens {
+		last := i == len(tokens)-1
+		switch container := v.(type) {
+		case map[string]interface{}:
+			path = append(path, token)
+			v = container[token]
+		case []interface{}:
+			index, ok := arrayIndex(token)
+			if !ok {
+				if last {
+					path = append(path, token)
+					continue
+				}
+				return nil, fmt.Errorf("%v does not exist", formatPointer(append(path, token)))
+			}
+			path = append(path, index)
+			if index < len(container) {
+				v = container[index]
+			} else if !last {
+				return nil, fmt.Errorf("%v does not exist", formatPointer(path))
+			}
+		default:
+			return nil, fmt.Errorf("%v does not exist", formatPointer(append(path, token)))
+		}
+	}
+	return path, nil
+}
+
+// Pointer renders the path as a RFC 6901 JSON Pointer such as /friends/1/email, the root is ""
+func (p Path) Pointer() string {
+	return formatPointer(p)
+}
+
+// Pointer returns the location of the result as a RFC 6901 JSON Pointer
+func (r *Result) Pointer() string {
+	return formatPointer(r.path)
+}
+
+// QueryPointer queries the value referenced by a RFC 6901 JSON Pointer such as /friends/1/email, "~1"
+// and "~0" in the reference tokens are unescaped into '/' and '~'. A token is an array index if it applies
+// to an array, and "-" or an index with leading zeros references no value. Return error when the pointer
+// is invalid
+func (s *searcher) QueryPointer(ptr string) (*Result, error) {
+	return s.Query().QueryPointer(ptr)
+}
+
+// QueryPointer is like searcher.QueryPointer but the pointer is relative to the result
+func (r *Result) QueryPointer(ptr string) (*Result, error) {
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if r.Type() == TypeArray {
+			index, ok := arrayIndex(token)
+			if !ok {
+				return &Result{path: r.path.child(token), src: r.src}, nil
+			}
+			r = r.Query(index)
+			continue
+		}
+		r = r.Query(token)
+	}
+	return r, nil
+}
@@ -0,0 +1,109 @@
+package jsonsearcher
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Position is a location in the json data passed to New. Offset is 0-based, Line and Column are 1-based,
+// and Column counts bytes like go/token does
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+var startPosition = Position{Line: 1, Column: 1}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// advance returns the position after data, which follows p in the input
+func (p Position) advance(data []byte) Position {
+	for _, c := range data {
+		p.Offset++
+		if c == '\n' {
+			p.Line++
+			p.Column = 1
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
+
+// Position returns the location where the value starts in the json data. ok is false if the location
+// is not known: the searcher is eager without KeepRaw, the document has been mutated, the searcher is
+// merged or patched, or the result is not from a document such as the result of Sum. The offset of the
+// value is found like Raw, and the first call on the searcher indexes the lines of the document
+func (r *Result) Position() (pos Position, ok bool) {
+	start, ok := r.offset()
+	if !ok {
+		return Position{}, false
+	}
+	return r.src.position(start), true
+}
+
+// position returns the position of the offset in data
+func (s *searcher) position(off int) Position {
+	s.linesOnce.Do(func() {
+		for i, c := range s.data {
+			if c == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	})
+	// n is the count of the lines before the one of off
+	n := sort.SearchInts(s.lines, off+1)
+	if n == 0 {
+		return Position{Offset: s.base.Offset + off, Line: s.base.Line, Column: s.base.Column + off}
+	}
+	return Position{Offset: s.base.Offset + off, Line: s.base.Line + n, Column: off - s.lines[n-1] + 1}
+}
+
+// SyntaxError is returned by New when the json data is invalid. Snippet is the text around the error
+// on its line
+type SyntaxError struct {
+	Position
+	Msg     string
+	Snippet string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("jsonsearcher: %s at line %d, column %d, near %q", e.Msg, e.Line, e.Column, e.Snippet)
+}
+
+// snippetRadius is the max count of bytes in the snippet of a SyntaxError on each side of the error
+const snippetRadius = 24
+
+// newSyntaxError converts the error of the scanner into a *SyntaxError
+func newSyntaxError(data []byte, err error) error {
+	scanErr, ok := err.(*scanError)
+	if !ok {
+		return err
+	}
+	off := scanErr.off
+	if off > len(data) {
+		off = len(data)
+	}
+
+	// The snippet stays on the line of the error and doesn't break a UTF-8 character
+	from := off
+	for from > 0 && off-from < snippetRadius && data[from-1] != '\n' {
+		from--
+	}
+	for from < off && !utf8.RuneStart(data[from]) {
+		from++
+	}
+	to := off
+	for to < len(data) && to-off < snippetRadius && data[to] != '\n' && data[to] != '\r' {
+		to++
+	}
+	for to < len(data) && to > off && !utf8.RuneStart(data[to]) {
+		to--
+	}
+
+	return &SyntaxError{Position: startPosition.advance(data[:off]), Msg: scanErr.msg, Snippet: string(data[from:to])}
+}
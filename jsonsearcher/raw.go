@@ -0,0 +1,110 @@
+package jsonsearcher
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"sync"
+)
+
+// RenderOptions controls how Render formats the json of a result
+type RenderOptions struct {
+	// Prefix and Indent format the output like json.MarshalIndent, the output is compact if both are empty
+	Prefix string
+	Indent string
+	// EscapeHTML escapes <, > and & in strings as \u003c, \u003e and \u0026, so the output is safe to be
+	// embedded in HTML
+	EscapeHTML bool
+}
+
+// Raw returns the original bytes of the value in the document, including the spaces inside it. The bytes
+// are shared with the searcher and must not be modified. The compact encoding of the value is returned if
+// the bytes are not kept: the searcher is eager without KeepRaw, the document has been mutated, or the
+// result is not from a document such as the result of Sum. Return nil if the result does not exist.
+// The first call on a result of an eager searcher walks the document along the path to find the value,
+// the later calls reuse its offset
+func (r *Result) Raw() []byte {
+	if !r.exists {
+		return nil
+	}
+	if raw := r.raw(); raw != nil {
+		return raw
+	}
+	data, _ := encodeValue(r.val(), r.src.orderTable(), "", "", false)
+	return data
+}
+
+// raw returns the original bytes of the value, or nil if they are not kept
+func (r *Result) raw() []byte {
+	if r.lazy != nil {
+		return r.lazy.raw
+	}
+	start, ok := r.offset()
+	if !ok {
+		return nil
+	}
+	end, _ := skipValue(r.src.data, start)
+	return r.src.data[start:end]
+}
+
+// location is the offset of the value of an eager result in the kept bytes, which is found on first use
+type location struct {
+	once sync.Once
+	off  int
+	ok   bool
+}
+
+// offset returns the offset of the value in src.data. The offset of a lazy result is known when it's
+// created, the one of an eager result is found by walking src.data along the path on the first call
+func (r *Result) offset() (int, bool) {
+	// The bytes are dropped once the document is mutated, and the results computed from the document
+	// have nil paths
+	if !r.exists || r.src == nil || r.src.data == nil || r.path == nil {
+		return 0, false
+	}
+	if r.lazy != nil {
+		return r.lazy.off, true
+	}
+	if r.loc == nil {
+		return 0, false
+	}
+	r.loc.once.Do(func() {
+		r.loc.off, r.loc.ok = findValue(r.src.data, r.path, r.src.dups)
+	})
+	return r.loc.off, r.loc.ok
+}
+
+// MarshalJSON implements json.Marshaler, so a result can be marshaled or embedded in other values
+// without decoding it. The output is the compact json of the value
+func (r *Result) MarshalJSON() ([]byte, error) {
+	return r.Render(RenderOptions{})
+}
+
+// Render returns the json of the value formatted with opts. The original bytes are reformatted if
+// they are kept, so the key order and the number literals are preserved
+func (r *Result) Render(opts RenderOptions) ([]byte, error) {
+	if !r.exists {
+		return nil, fmt.Errorf("jsonsearcher: %v does not exist", r.path)
+	}
+	raw := r.raw()
+	if raw == nil {
+		return encodeValue(r.val(), r.src.orderTable(), opts.Prefix, opts.Indent, opts.EscapeHTML)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if opts.Prefix == "" && opts.Indent == "" {
+		err = stdjson.Compact(&buf, raw)
+	} else {
+		err = stdjson.Indent(&buf, raw, opts.Prefix, opts.Indent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !opts.EscapeHTML {
+		return buf.Bytes(), nil
+	}
+	var escaped bytes.Buffer
+	stdjson.HTMLEscape(&escaped, buf.Bytes())
+	return escaped.Bytes(), nil
+}
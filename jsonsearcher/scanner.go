@@ -0,0 +1,456 @@
+package jsonsearcher
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// scanError is a syntax error found by the scanner, off is the byte offset of the error
+type scanError struct {
+	off int
+	msg string
+}
+
+func (e *scanError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.msg, e.off)
+}
+
+func scanErrorf(off int, format string, args ...interface{}) error {
+	return &scanError{off: off, msg: fmt.Sprintf(format, args...)}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// skipSpace returns the offset of the first non-whitespace byte at or after i
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// validate checks that data holds exactly one valid json value, surrounded by optional whitespace.
+// It returns the span of the value, and reports whether any object may have duplicate keys. If floats
+// is set, the numbers must be in the range of float64, so that the document is accepted only if it can
+// be decoded without UseNumber
+func validate(data []byte, floats bool) (int, int, bool, error) {
+	st := &scanState{floats: floats}
+	start := skipSpace(data, 0)
+	end, err := skipNested(data, start, 0, st)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if i := skipSpace(data, end); i != len(data) {
+		return 0, 0, false, scanErrorf(i, "invalid character %q after top-level value", data[i])
+	}
+	return start, end, st.dups, nil
+}
+
+// scanState is the state of validate, it's nil when a document which is validated already is walked
+type scanState struct {
+	floats bool
+	// dups is set if an object may have duplicate keys, a key with escapes sets it without comparing
+	dups bool
+	// keys are the raw keys of the objects being scanned, the ones of an inner object follow the outer's.
+	// sets index the keys of the big objects by the offset of their first keys in keys
+	keys [][]byte
+	sets map[int]map[string]bool
+}
+
+// addKey records a raw key of the object whose keys start at base in keys
+func (st *scanState) addKey(key []byte, base int) {
+	if st.dups {
+		return
+	}
+	if bytes.IndexByte(key, '\\') >= 0 {
+		st.dups = true
+		return
+	}
+	if set, ok := st.sets[base]; ok {
+		st.dups = set[string(key)]
+		set[string(key)] = true
+		return
+	}
+	for _, k := range st.keys[base:] {
+		if bytes.Equal(k, key) {
+			st.dups = true
+			return
+		}
+	}
+	st.keys = append(st.keys, key)
+	// A big object is indexed, so that each key is not compared with all the others
+	if len(st.keys)-base > 32 {
+		if st.sets == nil {
+			st.sets = make(map[int]map[string]bool)
+		}
+		set := make(map[string]bool, len(st.keys)-base)
+		for _, k := range st.keys[base:] {
+			set[string(k)] = true
+		}
+		st.sets[base] = set
+	}
+}
+
+// endObject drops the keys of the object whose keys start at base
+func (st *scanState) endObject(base int) {
+	st.keys = st.keys[:base]
+	delete(st.sets, base)
+}
+
+// maxDepth is the max nesting depth of objects and arrays which the scanners accept, it's the same as
+// jsoniter's. The scanners are recursive, so the depth is capped to keep malicious input from
+// overflowing the stack
+const maxDepth = 10000
+
+func depthError(off int) error {
+	return scanErrorf(off, "exceeded max depth %d", maxDepth)
+}
+
+// skipValue scans the value starting at i, the value is validated. It returns the offset just after the value
+func skipValue(data []byte, i int) (int, error) {
+	return skipNested(data, i, 0, nil)
+}
+
+// skipNested is skipValue for a value nested in depth objects and arrays, st is the state of validate
+func skipNested(data []byte, i int, depth int, st *scanState) (int, error) {
+	if i >= len(data) {
+		return i, scanErrorf(i, "unexpected end of json input")
+	}
+	switch c := data[i]; {
+	case c == '{' || c == '[':
+		if depth >= maxDepth {
+			return i, depthError(i)
+		}
+		if c == '{' {
+			return skipObject(data, i, depth+1, st)
+		}
+		return skipArray(data, i, depth+1, st)
+	case c == '"':
+		return skipString(data, i)
+	case c == 't':
+		return skipLiteral(data, i, "true")
+	case c == 'f':
+		return skipLiteral(data, i, "false")
+	case c == 'n':
+		return skipLiteral(data, i, "null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		end, err := skipNumber(data, i)
+		if err == nil && st != nil && st.floats {
+			err = checkFloat(data[i:end], i)
+		}
+		return end, err
+	default:
+		return i, scanErrorf(i, "invalid character %q looking for beginning of value", c)
+	}
+}
+
+func skipLiteral(data []byte, i int, lit string) (int, error) {
+	if !bytes.HasPrefix(data[i:], []byte(lit)) {
+		return i, scanErrorf(i, "invalid literal, expected %s", lit)
+	}
+	return i + len(lit), nil
+}
+
+func skipNumber(data []byte, i int) (int, error) {
+	start := i
+	if data[i] == '-' {
+		i++
+	}
+	if i >= len(data) {
+		return i, scanErrorf(i, "unexpected end of number")
+	}
+	switch {
+	case data[i] == '0':
+		i++
+	case data[i] >= '1' && data[i] <= '9':
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+	default:
+		return i, scanErrorf(i, "invalid character %q in number", data[i])
+	}
+	if i < len(data) && data[i] == '.' {
+		i++
+		digits := i
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		if i == digits {
+			return i, scanErrorf(i, "expected digit after decimal point in number %q", data[start:i])
+		}
+	}
+	if i < len(data) && (data[i] == 'e' || data[i] == 'E') {
+		i++
+		if i < len(data) && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+		digits := i
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		if i == digits {
+			return i, scanErrorf(i, "expected digit in exponent of number %q", data[start:i])
+		}
+	}
+	return i, nil
+}
+
+// skipString scans the string starting at the quote i, it returns the offset just after the closing quote
+func skipString(data []byte, i int) (int, error) {
+	i++
+	for i < len(data) {
+		c := data[i]
+		switch {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\':
+			i++
+			if i >= len(data) {
+				return i, scanErrorf(i, "unexpected end of string")
+			}
+			switch data[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				i++
+			case 'u':
+				i++
+				for k := 0; k < 4; k++ {
+					if i >= len(data) || !isHex(data[i]) {
+						return i, scanErrorf(i, "invalid unicode escape in string")
+					}
+					i++
+				}
+			default:
+				return i, scanErrorf(i, "invalid escape character %q in string", data[i])
+			}
+		case c < 0x20:
+			return i, scanErrorf(i, "invalid control character %q in string", c)
+		default:
+			i++
+		}
+	}
+	return i, scanErrorf(i, "unexpected end of string")
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// checkFloat returns an error if the number literal at off overflows float64. The literals which can't
+// overflow are not parsed
+func checkFloat(lit []byte, off int) error {
+	if len(lit) < 300 {
+		exp := false
+		for _, c := range lit {
+			if c == 'e' || c == 'E' {
+				exp = true
+				break
+			}
+		}
+		if !exp {
+			return nil
+		}
+	}
+	if _, err := strconv.ParseFloat(string(lit), 64); err != nil {
+		return scanErrorf(off, "number %s is out of the range of float64", lit)
+	}
+	return nil
+}
+
+func skipObject(data []byte, i int, depth int, st *scanState) (int, error) {
+	var err error
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return i + 1, nil
+	}
+	base := 0
+	if st != nil {
+		base = len(st.keys)
+		defer st.endObject(base)
+	}
+	for {
+		if i >= len(data) || data[i] != '"' {
+			return i, expectedError(data, i, "string for object key")
+		}
+		keyStart := i
+		if i, err = skipString(data, i); err != nil {
+			return i, err
+		}
+		if st != nil {
+			st.addKey(data[keyStart+1:i-1], base)
+		}
+		i = skipSpace(data, i)
+		if i >= len(data) || data[i] != ':' {
+			return i, expectedError(data, i, "':' after object key")
+		}
+		i = skipSpace(data, i+1)
+		if i, err = skipNested(data, i, depth, st); err != nil {
+			return i, err
+		}
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+			continue
+		}
+		if i < len(data) && data[i] == '}' {
+			return i + 1, nil
+		}
+		return i, expectedError(data, i, "',' or '}' after object value")
+	}
+}
+
+func skipArray(data []byte, i int, depth int, st *scanState) (int, error) {
+	var err error
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return i + 1, nil
+	}
+	for {
+		if i, err = skipNested(data, i, depth, st); err != nil {
+			return i, err
+		}
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+			continue
+		}
+		if i < len(data) && data[i] == ']' {
+			return i + 1, nil
+		}
+		return i, expectedError(data, i, "',' or ']' after array element")
+	}
+}
+
+func expectedError(data []byte, i int, what string) error {
+	if i >= len(data) {
+		return scanErrorf(i, "unexpected end of json input, expected %s", what)
+	}
+	return scanErrorf(i, "invalid character %q, expected %s", data[i], what)
+}
+
+// The functions below walk a document which is already validated, so they don't check the syntax again
+
+// findMember returns the offset of the value of key in the object starting at i. If dups is set, the
+// object may have duplicate keys, the whole object is scanned to find the last one like the decoder keeps
+func findMember(data []byte, i int, key string, dups bool) (int, bool) {
+	if data[i] != '{' {
+		return 0, false
+	}
+	found := -1
+	i = skipSpace(data, i+1)
+	for i < len(data) && data[i] == '"' {
+		keyEnd, _ := skipString(data, i)
+		match := keyEquals(data[i+1:keyEnd-1], key)
+		i = skipSpace(data, keyEnd)
+		i = skipSpace(data, i+1)
+		if match {
+			if !dups {
+				return i, true
+			}
+			found = i
+		}
+		end, _ := skipValue(data, i)
+		i = skipSpace(data, end)
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+	return found, found >= 0
+}
+
+// findElement returns the offset of the index-th element of the array starting at i
+func findElement(data []byte, i int, index int) (int, bool) {
+	if data[i] != '[' || index < 0 {
+		return 0, false
+	}
+	i = skipSpace(data, i+1)
+	for n := 0; i < len(data) && data[i] != ']'; n++ {
+		if n == index {
+			return i, true
+		}
+		end, _ := skipValue(data, i)
+		i = skipSpace(data, end)
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+	return 0, false
+}
+
+// forEachMember calls fn with the raw key(without quotes) and the offset of the value for each member of
+// the object starting at i, in document order. It stops when fn returns false
+func forEachMember(data []byte, i int, fn func(key []byte, start int) bool) {
+	i = skipSpace(data, i+1)
+	for i < len(data) && data[i] == '"' {
+		keyEnd, _ := skipString(data, i)
+		key := data[i+1 : keyEnd-1]
+		i = skipSpace(data, keyEnd)
+		i = skipSpace(data, i+1)
+		if !fn(key, i) {
+			return
+		}
+		end, _ := skipValue(data, i)
+		i = skipSpace(data, end)
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+}
+
+// forEachElement calls fn with the offset of each element of the array starting at i.
+// It stops when fn returns false
+func forEachElement(data []byte, i int, fn func(start int) bool) {
+	i = skipSpace(data, i+1)
+	for i < len(data) && data[i] != ']' {
+		if !fn(i) {
+			return
+		}
+		end, _ := skipValue(data, i)
+		i = skipSpace(data, end)
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+}
+
+// decodeKey decodes a raw object key(without quotes)
+func decodeKey(raw []byte) string {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw)
+	}
+	var decoded string
+	_ = json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &decoded)
+	return decoded
+}
+
+// keyEquals compares a raw object key(without quotes) with key, escapes in the raw key are decoded if needed
+func keyEquals(raw []byte, key string) bool {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw) == key
+	}
+	var decoded string
+	if err := json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &decoded); err != nil {
+		return false
+	}
+	return decoded == key
+}
+
+// rawType returns the type of the raw value by its first byte
+func rawType(raw []byte) resultType {
+	switch raw[0] {
+	case '{':
+		return TypeObject
+	case '[':
+		return TypeArray
+	case '"':
+		return TypeString
+	case 't', 'f':
+		return TypeBool
+	case 'n':
+		return TypeNull
+	default:
+		return TypeNumber
+	}
+}
@@ -0,0 +1,632 @@
+package schema
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+// Draft is a version of JSON Schema
+type Draft int
+
+const (
+	_ Draft = iota
+	Draft07
+	Draft202012
+)
+
+func (d Draft) String() string {
+	switch d {
+	case Draft07:
+		return "draft-07"
+	case Draft202012:
+		return "2020-12"
+	default:
+		return "InvalidDraft"
+	}
+}
+
+// drafts maps the $schema URIs(without scheme and trailing #) to the drafts
+var drafts = map[string]Draft{
+	"json-schema.org/draft-07/schema":      Draft07,
+	"json-schema.org/draft/2020-12/schema": Draft202012,
+}
+
+// CompileError is returned when the schema is invalid, SchemaPath is the location of the invalid keyword
+type CompileError struct {
+	SchemaPath string
+	Msg        string
+}
+
+func (e *CompileError) Error() string {
+	return fmt.Sprintf("invalid schema at %v: %v", e.SchemaPath, e.Msg)
+}
+
+type schema struct {
+	draft Draft
+	root  *node
+}
+
+// Draft returns the draft which the schema is compiled with
+func (s *schema) Draft() Draft {
+	return s.draft
+}
+
+// Compile compiles a JSON Schema. The draft is chosen by the $schema keyword of the root, draft-07 and
+// 2020-12 are supported, and 2020-12 is used if $schema is absent. Only local $refs(JSON Pointers
+// in the same document, such as #/$defs/user) are supported. format and unknown keywords are ignored
+func Compile(data []byte) (*schema, error) {
+	s, err := jsonsearcher.New(data)
+	if err != nil {
+		return nil, err
+	}
+	root := s.Query().GetValue()
+
+	c := &compiler{root: root, draft: Draft202012, nodes: make(map[string]*node)}
+	if obj, ok := root.(map[string]interface{}); ok {
+		if uri, ok := obj["$schema"]; ok {
+			str, _ := uri.(string)
+			id := strings.TrimSuffix(str, "#")
+			id = strings.TrimPrefix(strings.TrimPrefix(id, "http://"), "https://")
+			draft, ok := drafts[id]
+			if !ok {
+				return nil, &CompileError{SchemaPath: "#/$schema", Msg: fmt.Sprintf("unsupported $schema %q", str)}
+			}
+			c.draft = draft
+		}
+	}
+
+	n, err := c.compile(root, "#")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolveRefs(); err != nil {
+		return nil, err
+	}
+	return &schema{draft: c.draft, root: n}, nil
+}
+
+// node is a compiled schema object or boolean schema
+type node struct {
+	path string
+	// always is the result of a boolean schema
+	always *bool
+
+	ref     *node
+	refPath string
+
+	types      []string
+	enum       []interface{}
+	hasEnum    bool
+	constValue interface{}
+	hasConst   bool
+
+	minimum          *big.Rat
+	maximum          *big.Rat
+	exclusiveMinimum *big.Rat
+	exclusiveMaximum *big.Rat
+	multipleOf       *big.Rat
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	// prefixItems are the schemas of the leading elements, items is the schema of the rest elements
+	prefixItems []*node
+	items       *node
+	contains    *node
+	minContains *int
+	maxContains *int
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	properties        map[string]*node
+	patternProperties []patternProperty
+	additional        *node
+	required          []string
+	minProperties     *int
+	maxProperties     *int
+	propertyNames     *node
+	dependentRequired map[string][]string
+	dependentSchemas  map[string]*node
+	// dependentRequiredKeyword is dependencies in draft-07
+	dependentRequiredKeyword string
+
+	// unevaluatedProperties and unevaluatedItems apply to the members and elements which are not
+	// evaluated by the other keywords of the schema and its in-place subschemas
+	unevaluatedProperties *node
+	unevaluatedItems      *node
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+	if_   *node
+	then  *node
+	else_ *node
+}
+
+type patternProperty struct {
+	re   *regexp.Regexp
+	node *node
+}
+
+type compiler struct {
+	root  interface{}
+	draft Draft
+	// nodes are the compiled schemas by their paths, so that a schema referenced many times is compiled once
+	nodes map[string]*node
+}
+
+func (c *compiler) compile(v interface{}, path string) (*node, error) {
+	if n, ok := c.nodes[path]; ok {
+		return n, nil
+	}
+	n := &node{path: path}
+	c.nodes[path] = n
+
+	switch x := v.(type) {
+	case bool:
+		n.always = &x
+		return n, nil
+	case map[string]interface{}:
+		return n, c.compileObject(n, x)
+	default:
+		return nil, &CompileError{SchemaPath: path, Msg: "a schema must be an object or a boolean"}
+	}
+}
+
+func (c *compiler) compileObject(n *node, obj map[string]interface{}) error {
+	if ref, ok := obj["$ref"]; ok {
+		str, ok := ref.(string)
+		if !ok {
+			return c.errorf(n, "$ref", "must be a string")
+		}
+		n.refPath = str
+		// The other keywords are ignored beside $ref in draft-07
+		if c.draft == Draft07 {
+			return nil
+		}
+	}
+
+	var err error
+	get := func(keyword string, fn func(v interface{}) error) {
+		if v, ok := obj[keyword]; ok && err == nil {
+			err = fn(v)
+		}
+	}
+
+	get("type", func(v interface{}) error {
+		switch t := v.(type) {
+		case string:
+			n.types = []string{t}
+		case []interface{}:
+			for _, elem := range t {
+				str, ok := elem.(string)
+				if !ok {
+					return c.errorf(n, "type", "must be a string or an array of strings")
+				}
+				n.types = append(n.types, str)
+			}
+		default:
+			return c.errorf(n, "type", "must be a string or an array of strings")
+		}
+		for _, t := range n.types {
+			switch t {
+			case "null", "boolean", "object", "array", "number", "integer", "string":
+			default:
+				return c.errorf(n, "type", fmt.Sprintf("unknown type %q", t))
+			}
+		}
+		return nil
+	})
+	get("enum", func(v interface{}) error {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return c.errorf(n, "enum", "must be an array")
+		}
+		n.enum, n.hasEnum = arr, true
+		return nil
+	})
+	get("const", func(v interface{}) error {
+		n.constValue, n.hasConst = v, true
+		return nil
+	})
+
+	for _, kw := range []struct {
+		name string
+		dst  **big.Rat
+	}{
+		{"minimum", &n.minimum},
+		{"maximum", &n.maximum},
+		{"exclusiveMinimum", &n.exclusiveMinimum},
+		{"exclusiveMaximum", &n.exclusiveMaximum},
+		{"multipleOf", &n.multipleOf},
+	} {
+		kw := kw
+		get(kw.name, func(v interface{}) error {
+			f, ok := v.(float64)
+			if !ok {
+				return c.errorf(n, kw.name, "must be a number")
+			}
+			*kw.dst, _ = new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+			return nil
+		})
+	}
+	if n.multipleOf != nil && n.multipleOf.Sign() <= 0 {
+		return c.errorf(n, "multipleOf", "must be greater than 0")
+	}
+
+	for _, kw := range []struct {
+		name string
+		dst  **int
+	}{
+		{"minLength", &n.minLength},
+		{"maxLength", &n.maxLength},
+		{"minItems", &n.minItems},
+		{"maxItems", &n.maxItems},
+		{"minContains", &n.minContains},
+		{"maxContains", &n.maxContains},
+		{"minProperties", &n.minProperties},
+		{"maxProperties", &n.maxProperties},
+	} {
+		kw := kw
+		get(kw.name, func(v interface{}) error {
+			f, ok := v.(float64)
+			if !ok || f < 0 || f != float64(int(f)) {
+				return c.errorf(n, kw.name, "must be a non-negative integer")
+			}
+			i := int(f)
+			*kw.dst = &i
+			return nil
+		})
+	}
+
+	get("pattern", func(v interface{}) error {
+		re, err := c.regexp(n, "pattern", v)
+		n.pattern = re
+		return err
+	})
+	get("uniqueItems", func(v interface{}) error {
+		b, ok := v.(bool)
+		if !ok {
+			return c.errorf(n, "uniqueItems", "must be a boolean")
+		}
+		n.uniqueItems = b
+		return nil
+	})
+	get("required", func(v interface{}) error {
+		n.required, err = c.strings(n, "required", v)
+		return err
+	})
+
+	sub := func(keyword string, dst **node) {
+		get(keyword, func(v interface{}) error {
+			var err error
+			*dst, err = c.compile(v, n.path+"/"+keyword)
+			return err
+		})
+	}
+	subs := func(keyword string, dst *[]*node) {
+		get(keyword, func(v interface{}) error {
+			arr, ok := v.([]interface{})
+			if !ok || len(arr) == 0 {
+				return c.errorf(n, keyword, "must be a non-empty array of schemas")
+			}
+			for i, elem := range arr {
+				s, err := c.compile(elem, n.path+"/"+keyword+"/"+strconv.Itoa(i))
+				if err != nil {
+					return err
+				}
+				*dst = append(*dst, s)
+			}
+			return nil
+		})
+	}
+	props := func(keyword string, fn func(key string, s *node) error) {
+		get(keyword, func(v interface{}) error {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return c.errorf(n, keyword, "must be an object")
+			}
+			for _, key := range sortedKeys(m) {
+				s, err := c.compile(m[key], n.path+"/"+keyword+"/"+escapeToken(key))
+				if err != nil {
+					return err
+				}
+				if err := fn(key, s); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if c.draft == Draft07 {
+		// items is an array of schemas for the leading elements, or a schema for all elements
+		get("items", func(v interface{}) error {
+			arr, ok := v.([]interface{})
+			if !ok {
+				var err error
+				n.items, err = c.compile(v, n.path+"/items")
+				return err
+			}
+			n.prefixItems = []*node{}
+			for i, elem := range arr {
+				s, err := c.compile(elem, n.path+"/items/"+strconv.Itoa(i))
+				if err != nil {
+					return err
+				}
+				n.prefixItems = append(n.prefixItems, s)
+			}
+			return nil
+		})
+		if n.prefixItems != nil {
+			sub("additionalItems", &n.items)
+		}
+		get("dependencies", func(v interface{}) error {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return c.errorf(n, "dependencies", "must be an object")
+			}
+			for _, key := range sortedKeys(m) {
+				if arr, ok := m[key].([]interface{}); ok {
+					names, err := c.strings(n, "dependencies/"+escapeToken(key), arr)
+					if err != nil {
+						return err
+					}
+					if n.dependentRequired == nil {
+						n.dependentRequired = make(map[string][]string)
+						n.dependentRequiredKeyword = "dependencies"
+					}
+					n.dependentRequired[key] = names
+					continue
+				}
+				s, err := c.compile(m[key], n.path+"/dependencies/"+escapeToken(key))
+				if err != nil {
+					return err
+				}
+				if n.dependentSchemas == nil {
+					n.dependentSchemas = make(map[string]*node)
+				}
+				n.dependentSchemas[key] = s
+			}
+			return nil
+		})
+	} else {
+		subs("prefixItems", &n.prefixItems)
+		sub("items", &n.items)
+		get("dependentRequired", func(v interface{}) error {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return c.errorf(n, "dependentRequired", "must be an object")
+			}
+			n.dependentRequired = make(map[string][]string)
+			n.dependentRequiredKeyword = "dependentRequired"
+			for _, key := range sortedKeys(m) {
+				names, err := c.strings(n, "dependentRequired/"+escapeToken(key), m[key])
+				if err != nil {
+					return err
+				}
+				n.dependentRequired[key] = names
+			}
+			return nil
+		})
+		props("dependentSchemas", func(key string, s *node) error {
+			if n.dependentSchemas == nil {
+				n.dependentSchemas = make(map[string]*node)
+			}
+			n.dependentSchemas[key] = s
+			return nil
+		})
+		sub("unevaluatedProperties", &n.unevaluatedProperties)
+		sub("unevaluatedItems", &n.unevaluatedItems)
+	}
+
+	sub("contains", &n.contains)
+	props("properties", func(key string, s *node) error {
+		if n.properties == nil {
+			n.properties = make(map[string]*node)
+		}
+		n.properties[key] = s
+		return nil
+	})
+	props("patternProperties", func(key string, s *node) error {
+		re, err := c.regexp(n, "patternProperties/"+escapeToken(key), key)
+		if err != nil {
+			return err
+		}
+		n.patternProperties = append(n.patternProperties, patternProperty{re: re, node: s})
+		return nil
+	})
+	sub("additionalProperties", &n.additional)
+	sub("propertyNames", &n.propertyNames)
+
+	subs("allOf", &n.allOf)
+	subs("anyOf", &n.anyOf)
+	subs("oneOf", &n.oneOf)
+	sub("not", &n.not)
+	sub("if", &n.if_)
+	sub("then", &n.then)
+	sub("else", &n.else_)
+
+	// The schemas in $defs and definitions are compiled when they are referenced
+	return err
+}
+
+// resolveRefs links the $refs to their target schemas, the targets may be compiled here
+func (c *compiler) resolveRefs() error {
+	for {
+		var pending []*node
+		for _, n := range c.nodes {
+			if n.refPath != "" && n.ref == nil {
+				pending = append(pending, n)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		for _, n := range pending {
+			target, err := c.resolve(n)
+			if err != nil {
+				return err
+			}
+			n.ref = target
+		}
+	}
+
+	// A $ref cycle which doesn't move to a child value validates the same value forever, such as
+	// {"allOf":[{"$ref":"#"}]}
+	paths := make([]string, 0, len(c.nodes))
+	for path := range c.nodes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	state := make(map[*node]int)
+	for _, path := range paths {
+		if n := c.checkCycle(c.nodes[path], state, nil); n != nil {
+			return c.errorf(n, "$ref", fmt.Sprintf("circular $ref %q", n.refPath))
+		}
+	}
+	return nil
+}
+
+// inPlace returns the subschemas of n which apply to the same value as n
+func (n *node) inPlace() []*node {
+	var subs []*node
+	for _, s := range []*node{n.ref, n.not, n.if_, n.then, n.else_} {
+		if s != nil {
+			subs = append(subs, s)
+		}
+	}
+	subs = append(subs, n.allOf...)
+	subs = append(subs, n.anyOf...)
+	subs = append(subs, n.oneOf...)
+	keys := make([]string, 0, len(n.dependentSchemas))
+	for k := range n.dependentSchemas {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		subs = append(subs, n.dependentSchemas[k])
+	}
+	return subs
+}
+
+// checkCycle walks the in-place subschemas of n depth-first, and returns a node with $ref in the first
+// cycle found. state is 1 for the nodes on the stack, and 2 for the nodes checked already
+func (c *compiler) checkCycle(n *node, state map[*node]int, stack []*node) *node {
+	switch state[n] {
+	case 1:
+		// Every cycle has a $ref, since the other keywords only go deeper in the schema
+		for i := len(stack) - 1; i >= 0 && stack[i] != n; i-- {
+			if stack[i].ref != nil {
+				return stack[i]
+			}
+		}
+		return n
+	case 2:
+		return nil
+	}
+	state[n] = 1
+	stack = append(stack, n)
+	for _, s := range n.inPlace() {
+		if found := c.checkCycle(s, state, stack); found != nil {
+			return found
+		}
+	}
+	state[n] = 2
+	return nil
+}
+
+func (c *compiler) resolve(n *node) (*node, error) {
+	if !strings.HasPrefix(n.refPath, "#") {
+		return nil, c.errorf(n, "$ref", fmt.Sprintf("only local $refs are supported, got %q", n.refPath))
+	}
+	fragment, err := url.PathUnescape(n.refPath[1:])
+	if err != nil {
+		return nil, c.errorf(n, "$ref", err.Error())
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return nil, c.errorf(n, "$ref", fmt.Sprintf("only JSON Pointer fragments are supported, got %q", n.refPath))
+	}
+
+	v := c.root
+	path := "#"
+	if fragment != "" {
+		for _, token := range strings.Split(fragment[1:], "/") {
+			token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+			switch x := v.(type) {
+			case map[string]interface{}:
+				child, ok := x[token]
+				if !ok {
+					return nil, c.errorf(n, "$ref", fmt.Sprintf("%q does not exist", n.refPath))
+				}
+				v = child
+			case []interface{}:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(x) {
+					return nil, c.errorf(n, "$ref", fmt.Sprintf("%q does not exist", n.refPath))
+				}
+				v = x[i]
+			default:
+				return nil, c.errorf(n, "$ref", fmt.Sprintf("%q does not exist", n.refPath))
+			}
+			path += "/" + escapeToken(token)
+		}
+	}
+	return c.compile(v, path)
+}
+
+func (c *compiler) errorf(n *node, keyword string, msg string) error {
+	return &CompileError{SchemaPath: n.path + "/" + keyword, Msg: msg}
+}
+
+func (c *compiler) regexp(n *node, keyword string, v interface{}) (*regexp.Regexp, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, c.errorf(n, keyword, "must be a string")
+	}
+	re, err := regexp.Compile(str)
+	if err != nil {
+		return nil, c.errorf(n, keyword, err.Error())
+	}
+	return re, nil
+}
+
+func (c *compiler) strings(n *node, keyword string, v interface{}) ([]string, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, c.errorf(n, keyword, "must be an array of strings")
+	}
+	strs := make([]string, 0, len(arr))
+	for _, elem := range arr {
+		str, ok := elem.(string)
+		if !ok {
+			return nil, c.errorf(n, keyword, "must be an array of strings")
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapeToken escapes a JSON Pointer reference token
+func escapeToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,538 @@
+package schema
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+// Document is a json document to validate, both the searchers and *jsonsearcher.Result are documents
+type Document interface {
+	Query(args ...interface{}) *jsonsearcher.Result
+}
+
+// Violation is a failed keyword of the schema
+type Violation struct {
+	// InstancePath is the location of the invalid value in the document
+	InstancePath jsonsearcher.Path
+	// SchemaPath is the location of the failed keyword in the schema, such as #/properties/age/minimum
+	SchemaPath string
+	Keyword    string
+	Msg        string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%v: %v (%v)", v.InstancePath, v.Msg, v.SchemaPath)
+}
+
+// ValidationError is returned when the document doesn't match the schema, it has all the violations
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return "json schema validation failed: " + e.Violations[0].String()
+	}
+	return fmt.Sprintf("json schema validation failed: %v (and %d more violations)", e.Violations[0], len(e.Violations)-1)
+}
+
+// Validate validates the document, which is the value of a Result or the root of a searcher. Return a
+// *ValidationError with all the violations if the document doesn't match the schema
+func (s *schema) Validate(doc Document) error {
+	r := doc.Query()
+	if !r.Exists() {
+		return &ValidationError{Violations: []Violation{{
+			InstancePath: r.Path(), SchemaPath: "#", Msg: "the document does not exist",
+		}}}
+	}
+	v := &validator{}
+	if v.validate(s.root, r, nil) {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+type validator struct {
+	violations []Violation
+}
+
+// valid reports whether r matches n without recording violations, the annotations are recorded into ev
+// only if r is valid
+func valid(n *node, r *jsonsearcher.Result, ev *evaluated) bool {
+	if ev == nil {
+		return (&validator{}).validate(n, r, nil)
+	}
+	sub := &evaluated{}
+	if !(&validator{}).validate(n, r, sub) {
+		return false
+	}
+	ev.merge(sub)
+	return true
+}
+
+// evaluated records the members and elements of a value which are evaluated by a schema and its in-place
+// subschemas, unevaluatedProperties and unevaluatedItems apply to the rest. A nil *evaluated records nothing
+type evaluated struct {
+	props    map[string]bool
+	allProps bool
+	items    map[int]bool
+	allItems bool
+}
+
+func (ev *evaluated) prop(key string) {
+	if ev == nil {
+		return
+	}
+	if ev.props == nil {
+		ev.props = make(map[string]bool)
+	}
+	ev.props[key] = true
+}
+
+func (ev *evaluated) item(i int) {
+	if ev == nil {
+		return
+	}
+	if ev.items == nil {
+		ev.items = make(map[int]bool)
+	}
+	ev.items[i] = true
+}
+
+func (ev *evaluated) merge(other *evaluated) {
+	if ev == nil {
+		return
+	}
+	for key := range other.props {
+		ev.prop(key)
+	}
+	for i := range other.items {
+		ev.item(i)
+	}
+	ev.allProps = ev.allProps || other.allProps
+	ev.allItems = ev.allItems || other.allItems
+}
+
+func (v *validator) fail(n *node, keyword string, r *jsonsearcher.Result, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{
+		InstancePath: r.Path(),
+		SchemaPath:   n.path + "/" + keyword,
+		Keyword:      keyword,
+		Msg:          fmt.Sprintf(format, args...),
+	})
+}
+
+// validate records the violations of r against n, and reports whether r is valid. The evaluated members
+// and elements of r are recorded into ev if it's not nil
+func (v *validator) validate(n *node, r *jsonsearcher.Result, ev *evaluated) bool {
+	if n.always != nil {
+		if !*n.always {
+			v.violations = append(v.violations, Violation{
+				InstancePath: r.Path(), SchemaPath: n.path, Msg: "no value is allowed",
+			})
+		}
+		return *n.always
+	}
+
+	// The annotations are collected if n has unevaluated keywords, even if the caller doesn't need them
+	if ev == nil && (n.unevaluatedProperties != nil || n.unevaluatedItems != nil) {
+		ev = &evaluated{}
+	}
+
+	count := len(v.violations)
+	if n.ref != nil {
+		v.validate(n.ref, r, ev)
+	}
+
+	if n.types != nil && !matchType(n.types, r) {
+		v.fail(n, "type", r, "%v is not %v", typeName(r), strings.Join(n.types, " or "))
+	}
+	if n.hasConst && !equal(n.constValue, r.GetValue()) {
+		v.fail(n, "const", r, "must be equal to the constant")
+	}
+	if n.hasEnum {
+		found := false
+		for _, e := range n.enum {
+			if equal(e, r.GetValue()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(n, "enum", r, "must be one of the enum values")
+		}
+	}
+
+	switch r.Type() {
+	case jsonsearcher.TypeNumber:
+		v.validateNumber(n, r)
+	case jsonsearcher.TypeString:
+		v.validateString(n, r)
+	case jsonsearcher.TypeArray:
+		v.validateArray(n, r, ev)
+	case jsonsearcher.TypeObject:
+		v.validateObject(n, r, ev)
+	}
+
+	for _, s := range n.allOf {
+		v.validate(s, r, ev)
+	}
+	if n.anyOf != nil {
+		matched := false
+		for _, s := range n.anyOf {
+			if valid(s, r, ev) {
+				matched = true
+				// The annotations of all the matching schemas are collected
+				if ev == nil {
+					break
+				}
+			}
+		}
+		if !matched {
+			v.fail(n, "anyOf", r, "must match at least one schema of anyOf")
+		}
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, s := range n.oneOf {
+			if valid(s, r, ev) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(n, "oneOf", r, "must match exactly one schema of oneOf, matched %d", matched)
+		}
+	}
+	if n.not != nil && valid(n.not, r, nil) {
+		v.fail(n, "not", r, "must not match the schema of not")
+	}
+	if n.if_ != nil {
+		if valid(n.if_, r, ev) {
+			if n.then != nil {
+				v.validate(n.then, r, ev)
+			}
+		} else if n.else_ != nil {
+			v.validate(n.else_, r, ev)
+		}
+	}
+
+	// The unevaluated keywords run after all the other keywords, since they depend on their annotations
+	if n.unevaluatedProperties != nil && r.Type() == jsonsearcher.TypeObject && !ev.allProps {
+		r.ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			if k := key.GetString(); !ev.props[k] {
+				v.validateUnevaluated(n, "unevaluatedProperties", n.unevaluatedProperties, value, fmt.Sprintf("property %q", k))
+			}
+			return true
+		})
+		ev.allProps = true
+	}
+	if n.unevaluatedItems != nil && r.Type() == jsonsearcher.TypeArray && !ev.allItems {
+		i := 0
+		r.ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			if !ev.items[i] {
+				v.validateUnevaluated(n, "unevaluatedItems", n.unevaluatedItems, value, fmt.Sprintf("item %d", i))
+			}
+			i++
+			return true
+		})
+		ev.allItems = true
+	}
+
+	return len(v.violations) == count
+}
+
+// validateUnevaluated validates a member or an element which is not evaluated by the other keywords
+func (v *validator) validateUnevaluated(n *node, keyword string, s *node, value *jsonsearcher.Result, what string) {
+	if s.always != nil && !*s.always {
+		v.fail(n, keyword, value, "unevaluated %s is not allowed", what)
+		return
+	}
+	v.validate(s, value, nil)
+}
+
+func (v *validator) validateNumber(n *node, r *jsonsearcher.Result) {
+	x := toRat(r)
+	if x == nil {
+		// The exponent of the number is too large to be computed exactly, so the number keywords fail
+		// instead of being skipped
+		for _, kw := range []struct {
+			name  string
+			bound *big.Rat
+		}{
+			{"minimum", n.minimum},
+			{"maximum", n.maximum},
+			{"exclusiveMinimum", n.exclusiveMinimum},
+			{"exclusiveMaximum", n.exclusiveMaximum},
+			{"multipleOf", n.multipleOf},
+		} {
+			if kw.bound != nil {
+				_, err := r.Decimal()
+				v.fail(n, kw.name, r, "can not be validated: %v", err)
+			}
+		}
+		return
+	}
+	if n.minimum != nil && x.Cmp(n.minimum) < 0 {
+		v.fail(n, "minimum", r, "must be >= %v", n.minimum.RatString())
+	}
+	if n.maximum != nil && x.Cmp(n.maximum) > 0 {
+		v.fail(n, "maximum", r, "must be <= %v", n.maximum.RatString())
+	}
+	if n.exclusiveMinimum != nil && x.Cmp(n.exclusiveMinimum) <= 0 {
+		v.fail(n, "exclusiveMinimum", r, "must be > %v", n.exclusiveMinimum.RatString())
+	}
+	if n.exclusiveMaximum != nil && x.Cmp(n.exclusiveMaximum) >= 0 {
+		v.fail(n, "exclusiveMaximum", r, "must be < %v", n.exclusiveMaximum.RatString())
+	}
+	if n.multipleOf != nil && !new(big.Rat).Quo(x, n.multipleOf).IsInt() {
+		v.fail(n, "multipleOf", r, "must be a multiple of %v", n.multipleOf.RatString())
+	}
+}
+
+func (v *validator) validateString(n *node, r *jsonsearcher.Result) {
+	str := r.GetString()
+	length := utf8.RuneCountInString(str)
+	if n.minLength != nil && length < *n.minLength {
+		v.fail(n, "minLength", r, "length must be >= %d, got %d", *n.minLength, length)
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		v.fail(n, "maxLength", r, "length must be <= %d, got %d", *n.maxLength, length)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		v.fail(n, "pattern", r, "must match the pattern %q", n.pattern.String())
+	}
+}
+
+func (v *validator) validateArray(n *node, r *jsonsearcher.Result, ev *evaluated) {
+	var elems []*jsonsearcher.Result
+	r.ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+		elems = append(elems, value)
+		return true
+	})
+
+	if n.minItems != nil && len(elems) < *n.minItems {
+		v.fail(n, "minItems", r, "must have >= %d items, got %d", *n.minItems, len(elems))
+	}
+	if n.maxItems != nil && len(elems) > *n.maxItems {
+		v.fail(n, "maxItems", r, "must have <= %d items, got %d", *n.maxItems, len(elems))
+	}
+	if n.uniqueItems {
+	unique:
+		for i := range elems {
+			for j := 0; j < i; j++ {
+				if equal(elems[i].GetValue(), elems[j].GetValue()) {
+					v.fail(n, "uniqueItems", r, "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, elem := range elems {
+		if i < len(n.prefixItems) {
+			v.validate(n.prefixItems[i], elem, nil)
+			ev.item(i)
+		} else if n.items != nil {
+			v.validate(n.items, elem, nil)
+		}
+	}
+	if n.items != nil && ev != nil {
+		ev.allItems = true
+	}
+
+	if n.contains != nil {
+		matched := 0
+		for i, elem := range elems {
+			if valid(n.contains, elem, nil) {
+				matched++
+				ev.item(i)
+			}
+		}
+		min := 1
+		if n.minContains != nil {
+			min = *n.minContains
+		}
+		if matched < min {
+			v.fail(n, "contains", r, "must contain >= %d matching items, got %d", min, matched)
+		}
+		if n.maxContains != nil && matched > *n.maxContains {
+			v.fail(n, "maxContains", r, "must contain <= %d matching items, got %d", *n.maxContains, matched)
+		}
+	}
+}
+
+func (v *validator) validateObject(n *node, r *jsonsearcher.Result, ev *evaluated) {
+	var names []jsonsearcher.Result
+	var values []*jsonsearcher.Result
+	has := make(map[string]bool)
+	r.ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+		names = append(names, key)
+		values = append(values, value)
+		has[key.GetString()] = true
+		return true
+	})
+
+	if n.minProperties != nil && len(names) < *n.minProperties {
+		v.fail(n, "minProperties", r, "must have >= %d properties, got %d", *n.minProperties, len(names))
+	}
+	if n.maxProperties != nil && len(names) > *n.maxProperties {
+		v.fail(n, "maxProperties", r, "must have <= %d properties, got %d", *n.maxProperties, len(names))
+	}
+	for _, name := range n.required {
+		if !has[name] {
+			v.fail(n, "required", r, "missing required property %q", name)
+		}
+	}
+
+	for i := range names {
+		k, value := names[i].GetString(), values[i]
+		for _, name := range n.dependentRequired[k] {
+			if !has[name] {
+				v.fail(n, n.dependentRequiredKeyword, r, "property %q is required by %q", name, k)
+			}
+		}
+		if s, ok := n.dependentSchemas[k]; ok {
+			v.validate(s, r, ev)
+		}
+
+		if n.propertyNames != nil && !valid(n.propertyNames, &names[i], nil) {
+			v.fail(n, "propertyNames", value, "property name %q is invalid", k)
+		}
+
+		matched := false
+		if s, ok := n.properties[k]; ok {
+			matched = true
+			v.validate(s, value, nil)
+		}
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(k) {
+				matched = true
+				v.validate(pp.node, value, nil)
+			}
+		}
+		if matched || n.additional != nil {
+			ev.prop(k)
+		}
+		if !matched && n.additional != nil {
+			if n.additional.always != nil && !*n.additional.always {
+				v.fail(n, "additionalProperties", value, "additional property %q is not allowed", k)
+				continue
+			}
+			v.validate(n.additional, value, nil)
+		}
+	}
+}
+
+func matchType(types []string, r *jsonsearcher.Result) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if r.Type() == jsonsearcher.TypeNull {
+				return true
+			}
+		case "boolean":
+			if r.Type() == jsonsearcher.TypeBool {
+				return true
+			}
+		case "object":
+			if r.Type() == jsonsearcher.TypeObject {
+				return true
+			}
+		case "array":
+			if r.Type() == jsonsearcher.TypeArray {
+				return true
+			}
+		case "string":
+			if r.Type() == jsonsearcher.TypeString {
+				return true
+			}
+		case "number":
+			if r.Type() == jsonsearcher.TypeNumber {
+				return true
+			}
+		case "integer":
+			if x := toRat(r); x != nil && x.IsInt() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeName(r *jsonsearcher.Result) string {
+	switch r.Type() {
+	case jsonsearcher.TypeNull:
+		return "null"
+	case jsonsearcher.TypeBool:
+		return "boolean"
+	case jsonsearcher.TypeObject:
+		return "object"
+	case jsonsearcher.TypeArray:
+		return "array"
+	case jsonsearcher.TypeString:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// toRat returns the exact value of a number result, or nil if it's not a number
+func toRat(r *jsonsearcher.Result) *big.Rat {
+	dec, err := r.Decimal()
+	if err != nil {
+		return nil
+	}
+	x, _ := new(big.Rat).SetString(dec)
+	return x
+}
+
+// numberRat returns the exact value of a decoded json number
+func numberRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(n, 'g', -1, 64))
+	case stdjson.Number:
+		return new(big.Rat).SetString(string(n))
+	}
+	return nil, false
+}
+
+// equal reports whether two decoded json values are equal, numbers are compared by their values
+func equal(a, b interface{}) bool {
+	if x, ok := numberRat(a); ok {
+		y, ok := numberRat(b)
+		return ok && x.Cmp(y) == 0
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, x := range av {
+			y, ok := bv[k]
+			if !ok || !equal(x, y) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
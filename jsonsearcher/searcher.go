@@ -1,20 +1,101 @@
+// Package jsonsearcher reads values from json documents by keys, JSONPath expressions and JSON Pointers.
+//
+// Concurrency: a searcher is safe to be read by multiple goroutines at the same time, this includes Query,
+// QueryPath, QueryAll, QueryPointer, the compiled Query, Marshal, Diff, Merge(which reads its inputs) and all
+// the methods of the returned Results. The lazy decoding of a lazy searcher and its Results happens once
+// even if many goroutines trigger it. The mutating methods(Set, Delete, Insert, Append and ApplyPatch)
+// must not run concurrently with any other method of the same searcher, and the values returned by
+// GetValue, GetObject and GetArray are shared with the searcher, they must not be modified
 package jsonsearcher
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"errors"
+	"sync"
 
 	jsoniter "github.com/json-iterator/go"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-// New a json searcher. Return error when the json data is invalid
+// New a json searcher. Return error when the json data is invalid. The top-level value can be
+// any json value, such as an object, an array or a bare scalar
 func New(data []byte) (*searcher, error) {
-	obj := make(map[string]interface{})
-	if err := json.Unmarshal(data, &obj); err != nil {
+	return NewWithOptions(data, Options{})
+}
+
+// Options controls how the json data is parsed
+type Options struct {
+	// Lazy makes the searcher keep the raw bytes instead of decoding the whole document in advance.
+	// The bytes are validated once, then every Query scans them to locate the field, and values are
+	// only decoded when they are read. It is much faster when a few fields are read from a big document
+	Lazy bool
+	// UseNumber keeps numbers as json.Number instead of float64, so that the original literals are kept.
+	// Big integers and decimals can be read exactly by Int64, Uint64, BigInt, BigFloat and Decimal
+	UseNumber bool
+	// KeepRaw makes the searcher keep a copy of the json data, so that Raw returns the original bytes,
+	// Position is known and Decode reads the bytes directly. A lazy searcher always keeps the data
+	KeepRaw bool
+	// KeepOrder makes an eager searcher record the key order of the objects by a second scan of the data,
+	// so that Keys, ForEach and Marshal follow the document order instead of the sorted order. The order
+	// of a searcher which keeps the data is always known, it's recorded from the data on first use
+	KeepOrder bool
+
+	// The limits below protect the searcher from untrusted input, 0 means no limit. A *LimitError is
+	// returned by New if any of them is exceeded
+
+	// MaxDepth is the max nesting depth of objects and arrays, {"a":[1]} is of depth 2
+	MaxDepth int
+	// MaxSize is the max count of bytes of the json data
+	MaxSize int
+	// MaxStringLength is the max count of bytes of a decoded string, including object keys
+	MaxStringLength int
+	// MaxKeys is the max count of members of an object, duplicate keys are counted
+	MaxKeys int
+	// DuplicateKeys decides which value of a duplicate key is kept, or makes it an error. The members
+	// dropped are blanked out in the raw bytes, so all ways of reading the document agree
+	DuplicateKeys DuplicateKeyPolicy
+}
+
+// NewWithOptions news a json searcher with the options. Return error when the json data is invalid
+func NewWithOptions(data []byte, opts Options) (*searcher, error) {
+	if opts.checked() {
+		var err error
+		if data, err = check(data, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Lazy {
+		start, end, dups, err := validate(data, !opts.UseNumber)
+		if err != nil {
+			return nil, newSyntaxError(data, err)
+		}
+		return &searcher{data: data[start:end], base: startPosition.advance(data[:start]), lazy: true, dups: dups, useNumber: opts.UseNumber}, nil
+	}
+
+	s := &searcher{useNumber: opts.UseNumber}
+	if err := s.decoder().Unmarshal(data, &s.root); err != nil {
+		// The scanner finds where the error is. Its depth is capped like jsoniter's, so it stops at the
+		// same place on deeply nested input instead of overflowing the stack
+		if _, _, _, scanErr := validate(data, !opts.UseNumber); scanErr != nil {
+			return nil, newSyntaxError(data, scanErr)
+		}
 		return nil, err
 	}
-	return &searcher{obj: obj}, nil
+	start := skipSpace(data, 0)
+	if opts.KeepOrder && !opts.KeepRaw {
+		// The data is not kept, so the order can not be recorded on first use
+		s.order = make(orderTable)
+		s.order.record(data, start, s.root)
+	}
+	if opts.KeepRaw {
+		s.data = append([]byte(nil), bytes.TrimRight(data[start:], " \t\r\n")...)
+		s.base = startPosition.advance(data[:start])
+		// The bytes are not validated, so any object may have duplicate keys
+		s.dups = true
+	}
+	return s, nil
 }
 
 // ResultType is the type of json field
@@ -54,16 +135,124 @@ func (rt resultType) String() string {
 }
 
 type searcher struct {
-	obj map[string]interface{}
+	root interface{}
+
+	// data is the raw document, which is dropped after any mutation. root of a lazy searcher is decoded
+	// from it when the whole tree is needed, an eager searcher keeps it only with KeepRaw
+	data []byte
+	// base is the position of data in the input
+	base Position
+	lazy bool
+	once sync.Once
+	// err is the error of decoding data, it's not expected since data is validated
+	err error
+	// dups is set if an object of data may have duplicate keys, the last one of which is the value
+	dups bool
+
+	// lines are the offsets where the lines of data start, they are indexed on the first call of Position
+	lines     []int
+	linesOnce sync.Once
+
+	// useNumber makes numbers decoded as json.Number
+	useNumber bool
+
+	// order records the key order of the objects in the document. It's recorded from data on first use,
+	// or after parsing with KeepOrder if data is not kept. It's nil if the order is unknown
+	order     orderTable
+	orderOnce sync.Once
+
+	// origins records the layers which the values come from if the searcher is merged
+	origins map[string]int
+}
+
+// tree returns the decoded document, a lazy searcher decodes it on the first call. The root is nil if
+// decoding fails, the methods which can return errors check s.decode instead
+func (s *searcher) tree() interface{} {
+	if s.lazy {
+		s.once.Do(func() {
+			s.err = s.decoder().Unmarshal(s.data, &s.root)
+		})
+	}
+	return s.root
+}
+
+// decode decodes the document like tree, and returns the error of decoding
+func (s *searcher) decode() error {
+	s.tree()
+	return s.err
+}
+
+// decoder returns the json API which decodes the values of the searcher
+func (s *searcher) decoder() jsoniter.API {
+	if s != nil && s.useNumber {
+		return numberJSON
+	}
+	return json
 }
 
 // Query specific json field. Args' type must be int or string(if not, the function will panic)
 func (s *searcher) Query(args ...interface{}) *Result {
-	result := &Result{}
+	if s.lazy {
+		return s.queryRaw(s.data, 0, Path{}, args)
+	}
+	return s.queryTree(s.root, Path{}, args)
+}
+
+// Query queries a field inside the result, args are relative to the result like searcher.Query's.
+// The returned result has the full path from the root. A result which does not exist returns
+// results which do not exist either
+func (r *Result) Query(args ...interface{}) *Result {
+	if !r.exists {
+		return &Result{path: append(append(Path{}, r.path...), args...), src: r.src}
+	}
+	if r.lazy != nil {
+		return r.src.queryRaw(r.lazy.raw, r.lazy.off, r.path, args)
+	}
+	return r.src.queryTree(r.value, r.path, args)
+}
 
-	v := interface{}(s.obj)
-	for _, path := range args {
-		switch p := path.(type) {
+// queryRaw walks the raw json data along args, off is the offset of data in s.data and base is its path
+func (s *searcher) queryRaw(data []byte, off int, base Path, args []interface{}) *Result {
+	path := append(append(Path{}, base...), args...)
+	start, ok := findValue(data, args, s.dups)
+	if !ok {
+		return &Result{path: path, src: s}
+	}
+	end, _ := skipValue(data, start)
+	result := newRawResult(data[start:end], path)
+	result.lazy.off = off + start
+	result.src = s
+	return result
+}
+
+// findValue walks the raw json data along args, and returns the offset of the value found. dups is the
+// one of findMember
+func findValue(data []byte, args []interface{}, dups bool) (int, bool) {
+	start := 0
+	for _, arg := range args {
+		var ok bool
+		switch p := arg.(type) {
+		case int:
+			start, ok = findElement(data, start, p)
+		case string:
+			start, ok = findMember(data, start, p, dups)
+		default:
+			panic(errors.New("unexpected type"))
+		}
+		if !ok {
+			return 0, false
+		}
+	}
+	return start, true
+}
+
+// queryTree walks the decoded value v along args, base is the path of v
+func (s *searcher) queryTree(v interface{}, base Path, args []interface{}) *Result {
+	path := append(append(Path{}, base...), args...)
+	result := &Result{path: path, src: s}
+
+	for _, arg := range args {
+		switch p := arg.(type) {
 		case int:
 			value, ok := v.([]interface{})
 			if !ok {
@@ -87,30 +276,77 @@ func (s *searcher) Query(args ...interface{}) *Result {
 		}
 	}
 
-	result.exists = true
+	return s.result(v, path)
+}
+
+// result returns an existing result of the searcher
+func (s *searcher) result(v interface{}, path Path) *Result {
+	r := newResult(v, path)
+	r.src = s
+	if s != nil && s.data != nil {
+		r.loc = new(location)
+	}
+	return r
+}
+
+func newResult(v interface{}, path Path) *Result {
+	return &Result{resType: typeOf(v), exists: true, path: path, value: v}
+}
+
+// typeOf returns the type of a decoded json value
+func typeOf(v interface{}) resultType {
 	switch v.(type) {
-	case float64:
-		result.resType = TypeNumber
+	case float64, stdjson.Number:
+		return TypeNumber
 	case bool:
-		result.resType = TypeBool
+		return TypeBool
 	case string:
-		result.resType = TypeString
+		return TypeString
 	case []interface{}:
-		result.resType = TypeArray
+		return TypeArray
 	case map[string]interface{}:
-		result.resType = TypeObject
+		return TypeObject
 	case nil:
-		result.resType = TypeNull
+		return TypeNull
 	}
-	result.value = v
+	return resultType(0)
+}
 
-	return result
+// newRawResult returns a result of a lazy searcher, the value is decoded from raw on first access
+func newRawResult(raw []byte, path Path) *Result {
+	return &Result{resType: rawType(raw), exists: true, path: path, lazy: &lazyValue{raw: raw}}
 }
 
 type Result struct {
 	resType resultType
 	exists  bool
 	value   interface{}
+	path    Path
+	lazy    *lazyValue
+	// loc caches the offset of the value of an eager result in src.data, it's nil if the bytes are not kept
+	loc *location
+	// src is the searcher which the result is queried from
+	src *searcher
+}
+
+type lazyValue struct {
+	raw []byte
+	// off is the offset of raw in src.data
+	off   int
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// val returns the value of the result, decoding it if the result is from a lazy searcher
+func (r *Result) val() interface{} {
+	if r.lazy == nil {
+		return r.value
+	}
+	r.lazy.once.Do(func() {
+		r.lazy.err = r.src.decoder().Unmarshal(r.lazy.raw, &r.lazy.value)
+	})
+	return r.lazy.value
 }
 
 func (r *Result) Type() resultType {
@@ -121,55 +357,80 @@ func (r *Result) Exists() bool {
 	return r.exists
 }
 
+// child returns the result of a child value of r, key is the object key or the array index of the child
+func (r *Result) child(v interface{}, key interface{}) *Result {
+	return r.src.result(v, r.path.child(key))
+}
+
+// Path returns the location of the result in the document
+func (r *Result) Path() Path {
+	return r.path
+}
+
 func (r *Result) GetValue() interface{} {
-	return r.value
+	return r.val()
 }
 
+// GetInt64 truncates a float64 number as before, a json.Number of UseNumber mode is converted exactly
 func (r *Result) GetInt64() int64 {
-	if r.Type() != TypeNumber {
-		panic(errors.New("mistaken type of the result"))
+	if f, ok := r.val().(float64); ok && r.exists {
+		return int64(f)
 	}
-	return int64(r.value.(float64))
+	v, err := r.Int64()
+	if err != nil {
+		panic(err)
+	}
+	return v
 }
 
+// GetUint64 truncates a float64 number as before, a json.Number of UseNumber mode is converted exactly
 func (r *Result) GetUint64() uint64 {
-	if r.Type() != TypeNumber {
-		panic(errors.New("mistaken type of the result"))
+	if f, ok := r.val().(float64); ok && r.exists {
+		return uint64(f)
+	}
+	v, err := r.Uint64()
+	if err != nil {
+		panic(err)
 	}
-	return uint64(r.value.(float64))
+	return v
 }
 
 func (r *Result) GetFloat64() float64 {
-	if r.Type() != TypeNumber {
-		panic(errors.New("mistaken type of the result"))
+	v, err := r.Float64()
+	if err != nil {
+		panic(err)
 	}
-	return r.value.(float64)
+	return v
 }
 
 func (r *Result) GetBool() bool {
-	if r.Type() != TypeBool {
-		panic(errors.New("mistaken type of the result"))
+	v, err := r.Bool()
+	if err != nil {
+		panic(err)
 	}
-	return r.value.(bool)
+	return v
 }
 
 func (r *Result) GetString() string {
-	if r.Type() != TypeString {
-		panic(errors.New("mistaken type of the result"))
+	v, err := r.StringValue()
+	if err != nil {
+		panic(err)
 	}
-	return r.value.(string)
+	return v
 }
 
 func (r *Result) GetObject() map[string]interface{} {
-	if r.Type() != TypeObject {
-		panic(errors.New("mistaken type of the result"))
+	v, err := r.Object()
+	if err != nil {
+		panic(err)
 	}
-	return r.value.(map[string]interface{})
+	return v
 }
 
 func (r *Result) GetArray() []interface{} {
-	if r.Type() != TypeArray {
-		panic(errors.New("mistaken type of the result"))
+	v, err := r.Array()
+	if err != nil {
+		panic(err)
 	}
-	return r.value.([]interface{})
+	return v
 }
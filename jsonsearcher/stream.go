@@ -0,0 +1,426 @@
+package jsonsearcher
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// errStreamDone stops the walk when all the registered paths are found
+var errStreamDone = errors.New("all paths are found")
+
+// NewFromReader news a streaming json searcher over r. The whole document is never held in memory:
+// register the paths with Register, then Search tokenizes r incrementally and resolves all of them
+// in one pass. Only the values of the registered paths are decoded, and the reading stops as soon
+// as all of them are found
+func NewFromReader(r io.Reader) *streamSearcher {
+	return &streamSearcher{r: bufio.NewReader(r), results: make(map[string]*Result)}
+}
+
+type streamSearcher struct {
+	r   *bufio.Reader
+	off int
+
+	paths []Path
+	// found marks the paths which are found already
+	found   []bool
+	results map[string]*Result
+	pending int
+}
+
+// Register registers a path to search. Args' type must be int or string(if not, the function will panic).
+// It must be called before Search
+func (ss *streamSearcher) Register(args ...interface{}) {
+	for _, arg := range args {
+		switch arg.(type) {
+		case int, string:
+		default:
+			panic(errors.New("unexpected type"))
+		}
+	}
+	path := append(Path{}, args...)
+	if _, ok := ss.results[path.String()]; ok {
+		return
+	}
+	ss.paths = append(ss.paths, path)
+	ss.found = append(ss.found, false)
+	ss.results[path.String()] = nil
+	ss.pending++
+}
+
+// Search reads the document and resolves the registered paths. Return error when the json data
+// is invalid or reading fails
+func (ss *streamSearcher) Search() error {
+	if ss.pending == 0 {
+		return nil
+	}
+	if err := ss.walk(Path{}); err != nil {
+		if err == errStreamDone {
+			return nil
+		}
+		return err
+	}
+	c, err := ss.peekNonSpace()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return scanErrorf(ss.off, "invalid character %q after top-level value", c)
+}
+
+// Query returns the result of a registered path, which is valid after Search. The result of a path
+// not registered never exists
+func (ss *streamSearcher) Query(args ...interface{}) *Result {
+	path := append(Path{}, args...)
+	if r := ss.results[path.String()]; r != nil {
+		return r
+	}
+	return &Result{path: path}
+}
+
+// match returns the index of the path if it's registered and not found yet(-1 if not), and reports
+// whether it is a prefix of such a path
+func (ss *streamSearcher) match(path Path) (exact int, prefix bool) {
+	exact = -1
+	for n, p := range ss.paths {
+		if ss.found[n] || !p.hasPrefix(path) {
+			continue
+		}
+		if len(p) == len(path) {
+			exact = n
+		} else {
+			prefix = true
+		}
+	}
+	return
+}
+
+// resolve finds the registered paths inside the value v at path, since the bytes of v are consumed
+func (ss *streamSearcher) resolve(path Path, v interface{}) {
+	for n, p := range ss.paths {
+		if ss.found[n] || len(p) == len(path) || !p.hasPrefix(path) {
+			continue
+		}
+		r := (*searcher)(nil).queryTree(v, path, p[len(path):])
+		if !r.exists {
+			continue
+		}
+		ss.results[p.String()] = r
+		ss.found[n] = true
+		ss.pending--
+	}
+}
+
+func (ss *streamSearcher) walk(path Path) error {
+	c, err := ss.peekNonSpace()
+	if err != nil {
+		return ss.eofError(err)
+	}
+
+	exact, prefix := ss.match(path)
+	if exact >= 0 {
+		var buf bytes.Buffer
+		if err := ss.scanValue(&buf, len(path)); err != nil {
+			return err
+		}
+		var v interface{}
+		if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+			return err
+		}
+		ss.results[path.String()] = newResult(v, path)
+		ss.found[exact] = true
+		ss.pending--
+		ss.resolve(path, v)
+		if ss.pending == 0 {
+			return errStreamDone
+		}
+		return nil
+	}
+	if !prefix || (c != '{' && c != '[') {
+		return ss.scanValue(nil, len(path))
+	}
+
+	ss.readByte()
+	if c == '{' {
+		return ss.walkObject(path)
+	}
+	return ss.walkArray(path)
+}
+
+func (ss *streamSearcher) walkObject(path Path) error {
+	c, err := ss.peekNonSpace()
+	if err != nil {
+		return ss.eofError(err)
+	}
+	if c == '}' {
+		ss.readByte()
+		return nil
+	}
+	for {
+		if c != '"' {
+			return scanErrorf(ss.off, "invalid character %q, expected string for object key", c)
+		}
+		var key bytes.Buffer
+		if err := ss.scanString(&key); err != nil {
+			return err
+		}
+		var name string
+		if err := json.Unmarshal(key.Bytes(), &name); err != nil {
+			return err
+		}
+		if err := ss.expect(':', "':' after object key"); err != nil {
+			return err
+		}
+		if err := ss.walk(path.child(name)); err != nil {
+			return err
+		}
+		if c, err = ss.next("',' or '}' after object value"); err != nil {
+			return err
+		}
+		if c == '}' {
+			return nil
+		}
+		if c != ',' {
+			return scanErrorf(ss.off-1, "invalid character %q, expected ',' or '}' after object value", c)
+		}
+		if c, err = ss.peekNonSpace(); err != nil {
+			return ss.eofError(err)
+		}
+	}
+}
+
+func (ss *streamSearcher) walkArray(path Path) error {
+	c, err := ss.peekNonSpace()
+	if err != nil {
+		return ss.eofError(err)
+	}
+	if c == ']' {
+		ss.readByte()
+		return nil
+	}
+	for i := 0; ; i++ {
+		if err := ss.walk(path.child(i)); err != nil {
+			return err
+		}
+		if c, err = ss.next("',' or ']' after array element"); err != nil {
+			return err
+		}
+		if c == ']' {
+			return nil
+		}
+		if c != ',' {
+			return scanErrorf(ss.off-1, "invalid character %q, expected ',' or ']' after array element", c)
+		}
+	}
+}
+
+// scanValue reads and validates a value nested in depth objects and arrays, the bytes are written into
+// buf if it is not nil
+func (ss *streamSearcher) scanValue(buf *bytes.Buffer, depth int) error {
+	c, err := ss.peekNonSpace()
+	if err != nil {
+		return ss.eofError(err)
+	}
+	switch {
+	case c == '{' || c == '[':
+		if depth >= maxDepth {
+			return depthError(ss.off)
+		}
+		return ss.scanContainer(buf, depth+1)
+	case c == '"':
+		return ss.scanString(buf)
+	default:
+		// Numbers and literals end at a delimiter, the scanner of []byte validates them
+		var lit bytes.Buffer
+		start := ss.off
+		for {
+			c, err := ss.r.ReadByte()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if isSpace(c) || c == ',' || c == ']' || c == '}' || c == ':' {
+				ss.r.UnreadByte()
+				break
+			}
+			ss.off++
+			lit.WriteByte(c)
+		}
+		end, err := skipValue(lit.Bytes(), 0)
+		if err != nil {
+			err.(*scanError).off += start
+			return err
+		}
+		if end != lit.Len() {
+			return scanErrorf(start+end, "invalid character %q after value", lit.Bytes()[end])
+		}
+		if buf != nil {
+			buf.Write(lit.Bytes())
+		}
+		return nil
+	}
+}
+
+// scanContainer reads and validates an object or an array
+func (ss *streamSearcher) scanContainer(buf *bytes.Buffer, depth int) error {
+	open, _ := ss.readByte()
+	if buf != nil {
+		buf.WriteByte(open)
+	}
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+
+	c, err := ss.peekNonSpace()
+	if err != nil {
+		return ss.eofError(err)
+	}
+	if c == closing {
+		ss.readByte()
+		if buf != nil {
+			buf.WriteByte(c)
+		}
+		return nil
+	}
+	for {
+		if open == '{' {
+			if c != '"' {
+				return scanErrorf(ss.off, "invalid character %q, expected string for object key", c)
+			}
+			if err := ss.scanString(buf); err != nil {
+				return err
+			}
+			if err := ss.expect(':', "':' after object key"); err != nil {
+				return err
+			}
+			if buf != nil {
+				buf.WriteByte(':')
+			}
+		}
+		if err := ss.scanValue(buf, depth); err != nil {
+			return err
+		}
+		if c, err = ss.next("',' or '" + string(closing) + "'"); err != nil {
+			return err
+		}
+		if buf != nil {
+			buf.WriteByte(c)
+		}
+		if c == closing {
+			return nil
+		}
+		if c != ',' {
+			return scanErrorf(ss.off-1, "invalid character %q, expected ',' or '%c'", c, closing)
+		}
+		if c, err = ss.peekNonSpace(); err != nil {
+			return ss.eofError(err)
+		}
+	}
+}
+
+// scanString reads and validates a string including the quotes, the bytes are written into buf if it
+// is not nil. A skipped string is validated as it's read and never kept, so a huge one costs no memory
+func (ss *streamSearcher) scanString(buf *bytes.Buffer) error {
+	write := func(c byte) {
+		if buf != nil {
+			buf.WriteByte(c)
+		}
+	}
+	c, _ := ss.readByte()
+	write(c)
+	for {
+		c, err := ss.readByte()
+		if err != nil {
+			return ss.eofError(err)
+		}
+		switch {
+		case c == '"':
+			write(c)
+			return nil
+		case c == '\\':
+			write(c)
+			if c, err = ss.readByte(); err != nil {
+				return ss.eofError(err)
+			}
+			switch c {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				write(c)
+			case 'u':
+				write(c)
+				for k := 0; k < 4; k++ {
+					if c, err = ss.readByte(); err != nil {
+						return ss.eofError(err)
+					}
+					if !isHex(c) {
+						return scanErrorf(ss.off-1, "invalid unicode escape in string")
+					}
+					write(c)
+				}
+			default:
+				return scanErrorf(ss.off-1, "invalid escape character %q in string", c)
+			}
+		case c < 0x20:
+			return scanErrorf(ss.off-1, "invalid control character %q in string", c)
+		default:
+			write(c)
+		}
+	}
+}
+
+func (ss *streamSearcher) readByte() (byte, error) {
+	c, err := ss.r.ReadByte()
+	if err == nil {
+		ss.off++
+	}
+	return c, err
+}
+
+// peekNonSpace skips the whitespace and returns the next byte without consuming it
+func (ss *streamSearcher) peekNonSpace() (byte, error) {
+	for {
+		c, err := ss.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !isSpace(c) {
+			ss.r.UnreadByte()
+			return c, nil
+		}
+		ss.off++
+	}
+}
+
+// next skips the whitespace and consumes the next byte
+func (ss *streamSearcher) next(what string) (byte, error) {
+	if _, err := ss.peekNonSpace(); err != nil {
+		if err == io.EOF {
+			return 0, scanErrorf(ss.off, "unexpected end of json input, expected %s", what)
+		}
+		return 0, err
+	}
+	return ss.readByte()
+}
+
+func (ss *streamSearcher) expect(want byte, what string) error {
+	c, err := ss.next(what)
+	if err != nil {
+		return err
+	}
+	if c != want {
+		return scanErrorf(ss.off-1, "invalid character %q, expected %s", c, what)
+	}
+	return nil
+}
+
+func (ss *streamSearcher) eofError(err error) error {
+	if err == io.EOF {
+		return scanErrorf(ss.off, "unexpected end of json input")
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package searchertest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestAccessor(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(jsonString))
+
+	if v, err := s.Query("age").Int64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("age").Uint64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("age").Float64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("name").StringValue(); err != nil || v != "Markity" {
+		t.Fatalf("the value is %v %v, expected Markity", v, err)
+	}
+	if _, ok := interface{}(s.Query("name")).(fmt.Stringer); ok {
+		t.Fatalf("the result is a fmt.Stringer, expected not")
+	}
+	if v, err := s.Query("details").Object(); err != nil || len(v) != 1 {
+		t.Fatalf("the value is %v %v, expected map[interests:[golang python]]", v, err)
+	}
+	if v, err := s.Query("friends").Array(); err != nil || len(v) != 2 {
+		t.Fatalf("the value is %v %v, expected 2 friends", v, err)
+	}
+	if err := s.Query("phone").Null(); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	_, err := s.Query("friends", 0, "name").Int64()
+	var typeErr *jsonsearcher.TypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("err is %v, expected *jsonsearcher.TypeError", err)
+	}
+	if typeErr.Path.String() != "$.friends[0].name" || typeErr.Expected != jsonsearcher.TypeNumber || typeErr.Actual != jsonsearcher.TypeString {
+		t.Fatalf("err is %v, expected $.friends[0].name is StringType, expected NumberType", typeErr)
+	}
+
+	_, err = s.Query("friends", 0, "email").StringValue()
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("err is %v, expected *jsonsearcher.TypeError", err)
+	}
+	if typeErr.Path.String() != "$.friends[0].email" || typeErr.Expected != jsonsearcher.TypeString || typeErr.Actual.String() != "UndefinedType" {
+		t.Fatalf("err is %v, expected $.friends[0].email does not exist", typeErr)
+	}
+
+	if _, err := s.Query("phone").Bool(); err == nil {
+		t.Fatalf("err is nil, expected not nil")
+	}
+	if err := s.Query("name").Null(); err == nil {
+		t.Fatalf("err is nil, expected not nil")
+	}
+
+	defer func() {
+		if !errors.As(recover().(error), &typeErr) {
+			t.Fatalf("the panic value is not *jsonsearcher.TypeError")
+		}
+	}()
+	s.Query("undefined").GetBool()
+}
@@ -0,0 +1,124 @@
+package searchertest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const aggregateString = `{"friends":[{"name":"a","age":20.1},{"name":"b","age":30.2},{"name":"c","age":20.1},{"name":"d","age":10}],
+"tags":["x","y","x",1,1.0,{"k":1},{"k":1.0}]}`
+
+func TestAggregate(t *testing.T) {
+	for _, useNumber := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions([]byte(aggregateString), jsonsearcher.Options{UseNumber: useNumber})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		ages, _ := s.QueryAll("$.friends[*].age")
+
+		if n := jsonsearcher.Count(ages); n != 4 {
+			t.Fatalf("the count is %v, expected 4", n)
+		}
+		sum, err := jsonsearcher.Sum(ages)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if useNumber {
+			if v, _ := sum.Decimal(); v != "80.4" {
+				t.Fatalf("the sum is %v, expected exactly 80.4", v)
+			}
+		} else if v := sum.GetFloat64(); v < 80.39 || v > 80.41 {
+			t.Fatalf("the sum is %v, expected 80.4", v)
+		}
+		avg, err := jsonsearcher.Avg(ages)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if v := avg.GetFloat64(); v != 20.1 {
+			t.Fatalf("the avg is %v, expected 20.1", v)
+		}
+
+		min, err := jsonsearcher.Min(ages)
+		if err != nil || min.Path().String() != "$.friends[3].age" {
+			t.Fatalf("the min is %v %v, expected $.friends[3].age", min.Path(), err)
+		}
+		max, err := jsonsearcher.Max(ages)
+		if err != nil || max.GetFloat64() != 30.2 || max.Path().String() != "$.friends[1].age" {
+			t.Fatalf("the max is %v %v, expected $.friends[1].age", max.Path(), err)
+		}
+
+		distinct := jsonsearcher.Distinct(ages)
+		if len(distinct) != 3 || distinct[2].Path().String() != "$.friends[3].age" {
+			t.Fatalf("the distinct count is %v, expected 3", len(distinct))
+		}
+		tags, _ := s.QueryAll("$.tags[*]")
+		if distinct := jsonsearcher.Distinct(tags); len(distinct) != 4 {
+			t.Fatalf("the distinct count is %v, expected 4", len(distinct))
+		}
+	}
+}
+
+func TestAggregateEmpty(t *testing.T) {
+	if sum, err := jsonsearcher.Sum(nil); err != nil || sum.GetFloat64() != 0 {
+		t.Fatalf("the sum is %v %v, expected 0", sum.GetValue(), err)
+	}
+	for _, f := range []func([]*jsonsearcher.Result) (*jsonsearcher.Result, error){jsonsearcher.Avg, jsonsearcher.Min, jsonsearcher.Max} {
+		if r, err := f(nil); err != nil || r.Exists() {
+			t.Fatalf("the result is %v %v, expected not exist", r.GetValue(), err)
+		}
+	}
+}
+
+func TestAggregateExactAvg(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(`{"a":[1,1,2],"b":[9007199254740993,9007199254740993]}`), jsonsearcher.Options{UseNumber: true})
+	all, _ := s.QueryAll("$.a[*]")
+	if avg, _ := jsonsearcher.Avg(all); avg.GetFloat64() != 4.0/3 {
+		t.Fatalf("the avg is %v, expected 1.3333333333333333", avg.GetValue())
+	}
+	all, _ = s.QueryAll("$.b[*]")
+	if avg, _ := jsonsearcher.Avg(all); avg.GetInt64() != 9007199254740993 {
+		t.Fatalf("the avg is %v, expected 9007199254740993", avg.GetValue())
+	}
+}
+
+func TestAggregateNotNumber(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(aggregateString))
+	names, _ := s.QueryAll("$.friends[*].*")
+	_, err := jsonsearcher.Sum(names)
+	var typeErr *jsonsearcher.TypeError
+	if !errors.As(err, &typeErr) || typeErr.Path.String() != "$.friends[0].name" || typeErr.Actual != jsonsearcher.TypeString {
+		t.Fatalf("err is %v, expected $.friends[0].name is StringType", err)
+	}
+	if _, err := jsonsearcher.Max(names); err == nil {
+		t.Fatalf("err is nil, expected a *TypeError")
+	}
+}
+
+func TestAggregateHugeExponent(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(`[1,1e1000000000]`), jsonsearcher.Options{UseNumber: true})
+	all, _ := s.QueryAll("$[*]")
+	for _, f := range []func([]*jsonsearcher.Result) (*jsonsearcher.Result, error){jsonsearcher.Sum, jsonsearcher.Avg, jsonsearcher.Min, jsonsearcher.Max} {
+		var numErr *jsonsearcher.NumberError
+		if _, err := f(all); !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Path.String() != "$[1]" {
+			t.Fatalf("err is %v, expected overflow error of $[1]", err)
+		}
+	}
+}
+
+func TestDistinctMany(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, `"s%d",%d,`, i, i%100)
+	}
+	sb.WriteString(`"s0",1.0]`)
+	s, _ := jsonsearcher.New([]byte(sb.String()))
+	all, _ := s.QueryAll("$[*]")
+	if distinct := jsonsearcher.Distinct(all); len(distinct) != 20100 {
+		t.Fatalf("the distinct count is %v, expected 20100", len(distinct))
+	}
+}
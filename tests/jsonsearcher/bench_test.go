@@ -0,0 +1,67 @@
+package searchertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+// bigJSON is about 5MB, the benchmarks read three fields from it
+var bigJSON = func() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"name":"Markity","friends":[`)
+	for i := 0; i < 50000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(fmt.Sprintf(`{"id":%d,"name":"friend%d","email":"friend%d@qq.com","tags":["a","b","c"],"score":%d.5}`, i, i, i, i))
+	}
+	sb.WriteString(`],"total":50000}`)
+	return []byte(sb.String())
+}()
+
+func benchmarkThreeFields(b *testing.B, opts jsonsearcher.Options) {
+	b.SetBytes(int64(len(bigJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s, err := jsonsearcher.NewWithOptions(bigJSON, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if s.Query("name").GetString() != "Markity" {
+			b.Fatal("unexpected name")
+		}
+		if s.Query("friends", 100, "email").GetString() != "friend100@qq.com" {
+			b.Fatal("unexpected email")
+		}
+		if s.Query("total").GetInt64() != 50000 {
+			b.Fatal("unexpected total")
+		}
+	}
+}
+
+func BenchmarkEagerThreeFields(b *testing.B) {
+	benchmarkThreeFields(b, jsonsearcher.Options{})
+}
+
+func BenchmarkLazyThreeFields(b *testing.B) {
+	benchmarkThreeFields(b, jsonsearcher.Options{Lazy: true})
+}
+
+func BenchmarkEagerQuery(b *testing.B) {
+	s, _ := jsonsearcher.New(bigJSON)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Query("friends", 100, "email").GetString()
+	}
+}
+
+func BenchmarkLazyQuery(b *testing.B) {
+	s, _ := jsonsearcher.NewWithOptions(bigJSON, jsonsearcher.Options{Lazy: true})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Query("friends", 100, "email").GetString()
+	}
+}
@@ -0,0 +1,42 @@
+package searchertest
+
+import (
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestResultQuery(t *testing.T) {
+	eager, _ := jsonsearcher.New([]byte(jsonString))
+	lazy, _ := jsonsearcher.NewWithOptions([]byte(jsonString), jsonsearcher.Options{Lazy: true})
+
+	for _, s := range []interface {
+		Query(args ...interface{}) *jsonsearcher.Result
+	}{eager, lazy} {
+		friend := s.Query("friends", 1)
+		email := friend.Query("email")
+		if !email.Exists() || email.GetString() != s.Query("friends", 1, "email").GetString() {
+			t.Fatalf("email is %v, expected %v", email.GetValue(), s.Query("friends", 1, "email").GetValue())
+		}
+		if email.Path().String() != "$.friends[1].email" {
+			t.Fatalf("path is %v, expected $.friends[1].email", email.Path())
+		}
+		if v := s.Query("details").Query("interests").Query(0).GetString(); v != "golang" {
+			t.Fatalf("interest is %v, expected golang", v)
+		}
+		if v := s.Query("friends").Query(); v.Type() != jsonsearcher.TypeArray {
+			t.Fatalf("type is %v, expected ArrayType", v.Type())
+		}
+
+		missing := s.Query("friends", 5).Query("email")
+		if missing.Exists() {
+			t.Fatalf("missing exists, expected not exist")
+		}
+		if missing.Path().String() != "$.friends[5].email" {
+			t.Fatalf("path is %v, expected $.friends[5].email", missing.Path())
+		}
+		if s.Query("name").Query("first").Exists() {
+			t.Fatalf("$.name.first exists, expected not exist")
+		}
+	}
+}
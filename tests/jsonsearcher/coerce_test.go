@@ -0,0 +1,119 @@
+package searchertest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const coerceString = `{"num":16,"str":"16","frac":"1.9","big":"99999999999999999999","yes":"TRUE","one":1,"two":2,
+"flag":false,"word":"abc","dur":"1h30m","secs":1.5,"nanos":"0.0000000001","null":null,"obj":{}}`
+
+func TestCoerce(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(coerceString))
+
+	if v, err := s.Query("num").AsInt64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("str").AsInt64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("str").AsUint64(); err != nil || v != 16 {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("frac").AsFloat64(); err != nil || v != 1.9 {
+		t.Fatalf("the value is %v %v, expected 1.9", v, err)
+	}
+	if v, err := s.Query("yes").AsBool(); err != nil || !v {
+		t.Fatalf("the value is %v %v, expected true", v, err)
+	}
+	if v, err := s.Query("one").AsBool(); err != nil || !v {
+		t.Fatalf("the value is %v %v, expected true", v, err)
+	}
+	if v, err := s.Query("flag").AsBool(); err != nil || v {
+		t.Fatalf("the value is %v %v, expected false", v, err)
+	}
+	if v, err := s.Query("num").AsString(); err != nil || v != "16" {
+		t.Fatalf("the value is %v %v, expected 16", v, err)
+	}
+	if v, err := s.Query("flag").AsString(); err != nil || v != "false" {
+		t.Fatalf("the value is %v %v, expected false", v, err)
+	}
+	if v, err := s.Query("dur").AsDuration(); err != nil || v != 90*time.Minute {
+		t.Fatalf("the value is %v %v, expected 1h30m", v, err)
+	}
+	if v, err := s.Query("secs").AsDuration(); err != nil || v != 1500*time.Millisecond {
+		t.Fatalf("the value is %v %v, expected 1.5s", v, err)
+	}
+	if v, err := s.Query("str").AsDuration(); err != nil || v != 16*time.Second {
+		t.Fatalf("the value is %v %v, expected 16s", v, err)
+	}
+}
+
+func TestCoerceError(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(coerceString))
+
+	var numErr *jsonsearcher.NumberError
+	if _, err := s.Query("frac").AsInt64(); !errors.As(err, &numErr) || numErr.Reason != "fractional part" {
+		t.Fatalf("err is %v, expected fractional part error", err)
+	}
+	if _, err := s.Query("big").AsInt64(); !errors.As(err, &numErr) || numErr.Reason != "overflow" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	if _, err := s.Query("nanos").AsDuration(); !errors.As(err, &numErr) || numErr.Reason != "fractional part" {
+		t.Fatalf("err is %v, expected fractional part error", err)
+	}
+
+	var coerceErr *jsonsearcher.CoerceError
+	for _, c := range []struct {
+		name string
+		fn   func(r *jsonsearcher.Result) error
+	}{
+		{"word", func(r *jsonsearcher.Result) error { _, err := r.AsInt64(); return err }},
+		{"flag", func(r *jsonsearcher.Result) error { _, err := r.AsFloat64(); return err }},
+		{"two", func(r *jsonsearcher.Result) error { _, err := r.AsBool(); return err }},
+		{"word", func(r *jsonsearcher.Result) error { _, err := r.AsBool(); return err }},
+		{"obj", func(r *jsonsearcher.Result) error { _, err := r.AsString(); return err }},
+		{"null", func(r *jsonsearcher.Result) error { _, err := r.AsString(); return err }},
+		{"word", func(r *jsonsearcher.Result) error { _, err := r.AsDuration(); return err }},
+		{"missing", func(r *jsonsearcher.Result) error { _, err := r.AsInt64(); return err }},
+	} {
+		err := c.fn(s.Query(c.name))
+		if !errors.As(err, &coerceErr) || coerceErr.Path.String() != "$."+c.name {
+			t.Fatalf("err of %v is %v, expected *jsonsearcher.CoerceError", c.name, err)
+		}
+	}
+}
+
+func TestCoerceHugeExponent(t *testing.T) {
+	for _, useNumber := range []bool{false, true} {
+		data := `{"str":"1e100000000"}`
+		if useNumber {
+			data = `{"str":"1e100000000","num":1e100000000}`
+		}
+		s, err := jsonsearcher.NewWithOptions([]byte(data), jsonsearcher.Options{UseNumber: useNumber})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		var numErr *jsonsearcher.NumberError
+		if _, err := s.Query("str").AsDuration(); !errors.As(err, &numErr) || numErr.Reason != "overflow" {
+			t.Fatalf("err is %v, expected overflow error", err)
+		}
+		if _, err := s.Query("str").AsInt64(); !errors.As(err, &numErr) || numErr.Reason != "overflow" {
+			t.Fatalf("err is %v, expected overflow error", err)
+		}
+		if !useNumber {
+			continue
+		}
+		var coerceErr *jsonsearcher.CoerceError
+		if _, err := s.Query("num").AsBool(); !errors.As(err, &coerceErr) {
+			t.Fatalf("err is %v, expected *jsonsearcher.CoerceError", err)
+		}
+		if _, err := s.Query("num").AsDuration(); !errors.As(err, &numErr) || numErr.Reason != "overflow" {
+			t.Fatalf("err is %v, expected overflow error", err)
+		}
+	}
+}
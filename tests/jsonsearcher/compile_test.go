@@ -0,0 +1,114 @@
+package searchertest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestCompile(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(jsonString))
+
+	q, err := jsonsearcher.Compile("$.friends[1].email")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if q.String() != "$.friends[1].email" {
+		t.Fatalf("the query is %v, expected $.friends[1].email", q)
+	}
+	if r := q.First(s); r.GetString() != s.Query("friends", 1, "email").GetString() {
+		t.Fatalf("the value is %v, expected %v", r.GetValue(), s.Query("friends", 1, "email").GetValue())
+	}
+	if rs := q.All(s); len(rs) != 1 {
+		t.Fatalf("the count is %v, expected 1", len(rs))
+	}
+
+	names := jsonsearcher.MustCompile("$.friends[*].name")
+	if rs := names.All(s); len(rs) != 2 || rs[1].Path().String() != "$.friends[1].name" {
+		t.Fatalf("the results are %v, expected 2 names", len(rs))
+	}
+	if r := jsonsearcher.MustCompile("$.missing[*]").First(s); r.Exists() {
+		t.Fatalf("the result exists, expected not exist")
+	}
+
+	if _, err := jsonsearcher.Compile("$.friends["); err == nil {
+		t.Fatalf("err is nil, expected invalid path")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MustCompile doesn't panic, expected panic")
+		}
+	}()
+	jsonsearcher.MustCompile("$[?(@.a ==)]")
+}
+
+// TestConcurrentQuery runs the compiled queries against shared documents from many goroutines, it's
+// meaningful with the race detector: go test -race
+func TestConcurrentQuery(t *testing.T) {
+	name := jsonsearcher.MustCompile("$.name")
+	email := jsonsearcher.MustCompile("$.friends[1].email")
+	names := jsonsearcher.MustCompile("$..name")
+	filtered := jsonsearcher.MustCompile("$.friends[?(@.age >= 0)].name")
+	root := jsonsearcher.MustCompile("$")
+	friends := jsonsearcher.MustCompile("$.friends")
+
+	// doc runs the queries against a document, first returns the first match and all returns all the matches
+	type doc struct {
+		index int
+		first func(q *jsonsearcher.Query) *jsonsearcher.Result
+		all   func(q *jsonsearcher.Query) []*jsonsearcher.Result
+	}
+	var docs []doc
+	for i := 0; i < 8; i++ {
+		data := []byte(fmt.Sprintf(`{"name":"doc%d","friends":[{"name":"a","age":%d},{"name":"b","age":1,"email":"b%d@qq.com"}]}`, i, i, i))
+		for _, lazy := range []bool{false, true} {
+			s, _ := jsonsearcher.NewWithOptions(data, jsonsearcher.Options{Lazy: lazy})
+			docs = append(docs, doc{
+				index: i,
+				first: func(q *jsonsearcher.Query) *jsonsearcher.Result { return q.First(s) },
+				all:   func(q *jsonsearcher.Query) []*jsonsearcher.Result { return q.All(s) },
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				for _, d := range docs {
+					if v := d.first(name).GetString(); v != fmt.Sprintf("doc%d", d.index) {
+						errs <- fmt.Errorf("the name of doc%d is %v", d.index, v)
+						return
+					}
+					if v := d.first(email).GetString(); v != fmt.Sprintf("b%d@qq.com", d.index) {
+						errs <- fmt.Errorf("the email of doc%d is %v", d.index, v)
+						return
+					}
+					if rs := d.all(names); len(rs) != 3 {
+						errs <- fmt.Errorf("the count of names of doc%d is %v, expected 3", d.index, len(rs))
+						return
+					}
+					if rs := d.all(filtered); len(rs) != 2 {
+						errs <- fmt.Errorf("the count of filtered names of doc%d is %v, expected 2", d.index, len(rs))
+						return
+					}
+					d.all(root)[0].Keys()
+					d.first(friends).ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+						value.Keys()
+						return true
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package searchertest
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+type friend struct {
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Email string `json:"email"`
+}
+
+type person struct {
+	Name    string              `json:"name"`
+	Age     uint8               `json:"age"`
+	Phone   *string             `json:"phone"`
+	Friends []friend            `json:"friends"`
+	Details map[string][]string `json:"details"`
+}
+
+func TestDecode(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(jsonString), jsonsearcher.Options{Lazy: lazy})
+
+		var f friend
+		if err := s.Query("friends", 1).Decode(&f); err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if f.Name != s.Query("friends", 1, "name").GetString() || f.Email != s.Query("friends", 1, "email").GetString() {
+			t.Fatalf("friend is %+v, expected %v", f, s.Query("friends", 1).GetValue())
+		}
+
+		var p person
+		if err := s.Query().DecodeWithOptions(&p, jsonsearcher.DecodeOptions{Strict: true}); err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if p.Name != "Markity" || len(p.Friends) != 2 || p.Phone != nil || p.Details["interests"][0] != "golang" {
+			t.Fatalf("person is %+v, expected decoded", p)
+		}
+
+		var names []string
+		err := s.Query("friends").Decode(&names)
+		var decErr *jsonsearcher.DecodeError
+		if !errors.As(err, &decErr) || decErr.Path.String() != "$.friends[0]" || decErr.Err == nil {
+			t.Fatalf("err is %v, expected $.friends[0] can not be decoded", err)
+		}
+
+		var strict struct {
+			Name string `json:"name"`
+		}
+		err = s.Query("friends", 0).DecodeWithOptions(&strict, jsonsearcher.DecodeOptions{Strict: true})
+		if !errors.As(err, &decErr) || decErr.Path.String() != "$.friends[0].age" {
+			t.Fatalf("err is %v, expected unknown field $.friends[0].age", err)
+		}
+		if err := s.Query("friends", 0).Decode(&strict); err != nil || strict.Name == "" {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		if err := s.Query("friends", 9).Decode(&f); !errors.As(err, &decErr) || decErr.Path.String() != "$.friends[9]" {
+			t.Fatalf("err is %v, expected $.friends[9] does not exist", err)
+		}
+		if err := s.Query("friends", 0).Decode(f); err == nil {
+			t.Fatalf("err is nil, expected an error for a non-pointer")
+		}
+	}
+}
+
+func TestDecodeNumberError(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(`{"list":[{"n":1},{"n":300}],"f":{"n":1.5}}`))
+
+	var v struct {
+		List []struct {
+			N int8 `json:"n"`
+		} `json:"list"`
+	}
+	err := s.Query().Decode(&v)
+	var decErr *jsonsearcher.DecodeError
+	if !errors.As(err, &decErr) || decErr.Path.String() != "$.list[1].n" {
+		t.Fatalf("err is %v, expected $.list[1].n overflows", err)
+	}
+
+	var w struct {
+		N int `json:"n"`
+	}
+	if err := s.Query("f").Decode(&w); !errors.As(err, &decErr) || decErr.Path.String() != "$.f.n" {
+		t.Fatalf("err is %v, expected $.f.n has a fractional part", err)
+	}
+}
+
+func TestDecodeRawBytes(t *testing.T) {
+	data := `{"friend": {"name": "Jack", "score": 1.50}}`
+	cases := []struct {
+		opts     jsonsearcher.Options
+		expected string
+	}{
+		{jsonsearcher.Options{KeepRaw: true}, `{"name": "Jack", "score": 1.50}`},
+		{jsonsearcher.Options{Lazy: true}, `{"name": "Jack", "score": 1.50}`},
+		{jsonsearcher.Options{}, `{"name":"Jack","score":1.5}`},
+	}
+	for _, c := range cases {
+		s, _ := jsonsearcher.NewWithOptions([]byte(data), c.opts)
+		// json.RawMessage shows whether the original bytes are decoded without encoding them again
+		var raw json.RawMessage
+		if err := s.Query("friend").Decode(&raw); err != nil || string(raw) != c.expected {
+			t.Fatalf("the raw message is %s %v, expected %s", raw, err, c.expected)
+		}
+	}
+
+	s, _ := jsonsearcher.NewWithOptions([]byte(data), jsonsearcher.Options{KeepRaw: true})
+	_ = s.Set(jsonsearcher.Path{"friend", "name"}, "Tom")
+	var f friend
+	if err := s.Query("friend").Decode(&f); err != nil || f.Name != "Tom" {
+		t.Fatalf("the friend is %+v %v, expected the mutated value", f, err)
+	}
+}
@@ -0,0 +1,61 @@
+package searchertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func formatChanges(changes []jsonsearcher.Change) string {
+	strs := make([]string, 0, len(changes))
+	for _, c := range changes {
+		strs = append(strs, fmt.Sprintf("%v %v %v->%v", c.Kind, c.Path, c.Old, c.New))
+	}
+	return strings.Join(strs, "; ")
+}
+
+func TestDiff(t *testing.T) {
+	a, _ := jsonsearcher.New([]byte(`{"name":"Markity","age":16,"friends":[{"name":"Jack"},{"name":"Mary"}],"phone":null,"score":1.0,"meta":{"id":"x"}}`))
+	b, _ := jsonsearcher.New([]byte(`{"name":"Markity","age":"16","friends":[{"name":"Mary"},{"name":"Jack"},{"name":"Tom"}],"email":"a@b.c","score":1.001,"meta":{"id":"y"}}`))
+
+	expected := "TypeChanged $.age 16->16; Added $.email <nil>->a@b.c; " +
+		"Changed $.friends[0].name Jack->Mary; Changed $.friends[1].name Mary->Jack; Added $.friends[2] <nil>->map[name:Tom]; " +
+		"Changed $.meta.id x->y; Removed $.phone <nil>-><nil>; Changed $.score 1->1.001"
+	if got := formatChanges(jsonsearcher.Diff(a, b)); got != expected {
+		t.Fatalf("the changes are %v, expected %v", got, expected)
+	}
+
+	changes, err := jsonsearcher.DiffWithOptions(a, b, jsonsearcher.DiffOptions{
+		IgnorePaths:     []string{"$.meta.id", "$.phone"},
+		UnorderedArrays: true,
+		Tolerance:       0.01,
+	})
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	expected = "TypeChanged $.age 16->16; Added $.email <nil>->a@b.c; Added $.friends[2] <nil>->map[name:Tom]"
+	if got := formatChanges(changes); got != expected {
+		t.Fatalf("the changes are %v, expected %v", got, expected)
+	}
+
+	changes, _ = jsonsearcher.DiffWithOptions(a, b, jsonsearcher.DiffOptions{IgnorePaths: []string{"$..name", "$.*"}})
+	if len(changes) != 0 {
+		t.Fatalf("the changes are %v, expected none", formatChanges(changes))
+	}
+
+	x, _ := jsonsearcher.New([]byte(`[1,2,2,3]`))
+	y, _ := jsonsearcher.New([]byte(`[2,3,1,4]`))
+	changes, _ = jsonsearcher.DiffWithOptions(x, y, jsonsearcher.DiffOptions{UnorderedArrays: true})
+	if got := formatChanges(changes); got != "Removed $[2] 2-><nil>; Added $[3] <nil>->4" {
+		t.Fatalf("the changes are %v, expected Removed $[2] 2-><nil>; Added $[3] <nil>->4", got)
+	}
+
+	if len(jsonsearcher.Diff(a, a)) != 0 {
+		t.Fatalf("the document differs from itself")
+	}
+	if _, err := jsonsearcher.DiffWithOptions(a, b, jsonsearcher.DiffOptions{IgnorePaths: []string{"$["}}); err == nil {
+		t.Fatalf("err is nil, expected not nil")
+	}
+}
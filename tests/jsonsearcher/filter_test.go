@@ -0,0 +1,79 @@
+package searchertest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+var filterJSON = `
+{
+	"threshold":18,
+	"friends":[
+		{"name":"Jack","age":17,"tags":["a"]},
+		{"name":"Mary","age":18,"email":"mary@qq.com","tags":[]},
+		{"name":"Tom","age":30,"email":"tom@gmail.com","active":true},
+		{"name":"Lily","age":null,"email":"LILY@QQ.COM"}
+	]
+}
+`
+
+func TestFilter(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(filterJSON))
+
+	names := func(path string) string {
+		results, err := s.QueryAll(path)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil for %v", err, path)
+		}
+		strs := make([]string, 0, len(results))
+		for _, r := range results {
+			strs = append(strs, r.GetString())
+		}
+		return strings.Join(strs, ",")
+	}
+
+	cases := map[string]string{
+		"$.friends[?(@.age >= 18 && @.email)].name":          "Mary,Tom",
+		"$.friends[?(@.age>=18&&@.email)].name":              "Mary,Tom",
+		"$.friends[?@.age < 18].name":                        "Jack",
+		"$.friends[?(@.age == 18 || @.active == true)].name": "Mary,Tom",
+		"$.friends[?(!@.email)].name":                        "Jack",
+		"$.friends[?(@.age == null)].name":                   "Lily",
+		"$.friends[?(@.age != 17)].name":                     "Mary,Tom,Lily",
+		"$.friends[?(@.name == 'Tom')].email":                "tom@gmail.com",
+		`$.friends[?(@.name > "Lily")].name`:                 "Mary,Tom",
+		"$.friends[?(@.email =~ /@qq\\.com$/)].name":         "Mary",
+		"$.friends[?(@.email =~ /@qq\\.com$/i)].name":        "Mary,Lily",
+		"$.friends[?(@.email =~ '^tom')].name":               "Tom",
+		"$.friends[?(@.age >= $.threshold)].name":            "Mary,Tom",
+		"$.friends[?(@.tags[0])].name":                       "Jack",
+		"$.friends[?(@.tags)].name":                          "Jack,Mary",
+		"$.friends[?(!(@.age < 18 || @.age > 20))].name":     "Mary,Lily",
+		"$.friends[?(@.age > 'a')].name":                     "",
+		"$..[?(@.age == 30)].name":                           "Tom",
+		"$.friends[?(@.missing == @.other)].name":            "Jack,Mary,Tom,Lily",
+	}
+	for path, expected := range cases {
+		if got := names(path); got != expected {
+			t.Fatalf("the results of %v are %v, expected %v", path, got, expected)
+		}
+	}
+
+	invalid := []string{
+		"$.friends[?(@.age >= 18]",
+		"$.friends[?(@.age >=)]",
+		"$.friends[?(18)]",
+		"$.friends[?(@.email =~ /[/)]",
+		"$.friends[?(@.email =~ 18)]",
+		"$.friends[?(@.tags[*] == 'a')]",
+		"$.friends[?(@.age = 18)]",
+		"$.friends[?(@.age == truex)]",
+	}
+	for _, path := range invalid {
+		if _, err := s.QueryAll(path); err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", path)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package searchertest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const findString = `{"user":{"password":"secret","name":"Markity"},"friends":[{"name":"A","password":"secret"},{"name":"B"}],"password":"root"}`
+
+func TestFindPaths(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions([]byte(findString), jsonsearcher.Options{Lazy: lazy, KeepOrder: true})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		paths := s.FindPaths(func(path jsonsearcher.Path, r *jsonsearcher.Result) bool {
+			return r.Type() == jsonsearcher.TypeString && r.GetString() == "secret"
+		})
+		var strs []string
+		for _, p := range paths {
+			strs = append(strs, p.String())
+			if s.Query(p...).GetString() != "secret" {
+				t.Fatalf("the value at %v is %v, expected secret", p, s.Query(p...).GetValue())
+			}
+		}
+		if strings.Join(strs, ",") != "$.user.password,$.friends[0].password" {
+			t.Fatalf("the paths are %v, expected [$.user.password $.friends[0].password]", strs)
+		}
+
+		paths = s.FindPaths(func(path jsonsearcher.Path, r *jsonsearcher.Result) bool {
+			return len(path) > 0 && path[len(path)-1] == "password"
+		})
+		if len(paths) != 3 || paths[2].String() != "$.password" {
+			t.Fatalf("the paths are %v, expected 3 passwords", paths)
+		}
+
+		paths = s.FindPaths(func(path jsonsearcher.Path, r *jsonsearcher.Result) bool {
+			return r.Type() == jsonsearcher.TypeObject
+		})
+		if len(paths) != 4 || len(paths[0]) != 0 || paths[3].String() != "$.friends[1]" {
+			t.Fatalf("the paths are %v, expected the root and 3 objects", paths)
+		}
+	}
+}
+
+func TestFindPathsRedact(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(findString))
+	for _, p := range s.FindPaths(func(path jsonsearcher.Path, r *jsonsearcher.Result) bool {
+		return len(path) > 0 && path[len(path)-1] == "password"
+	}) {
+		if err := s.Set(p, "***"); err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+	}
+	if v := s.Query("friends", 0, "password").GetString(); v != "***" {
+		t.Fatalf("the value is %v, expected ***", v)
+	}
+}
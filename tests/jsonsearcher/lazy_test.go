@@ -0,0 +1,165 @@
+package searchertest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestLazy(t *testing.T) {
+	eager, _ := jsonsearcher.New([]byte(jsonString))
+	lazy, err := jsonsearcher.NewWithOptions([]byte(jsonString), jsonsearcher.Options{Lazy: true})
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	queries := [][]interface{}{
+		{},
+		{"name"},
+		{"age"},
+		{"friends"},
+		{"friends", 0},
+		{"friends", 1, "email"},
+		{"friends", 0, "email"},
+		{"friends", 2},
+		{"friends", -1},
+		{"details", "interests", 1},
+		{"phone"},
+		{"phone", 0},
+		{"undefined"},
+		{0},
+	}
+	for _, args := range queries {
+		r1, r2 := eager.Query(args...), lazy.Query(args...)
+		if r1.Exists() != r2.Exists() || r1.Type() != r2.Type() {
+			t.Fatalf("the results of %v are different: %v %v and %v %v", args, r1.Exists(), r1.Type(), r2.Exists(), r2.Type())
+		}
+		if !reflect.DeepEqual(r1.GetValue(), r2.GetValue()) {
+			t.Fatalf("the values of %v are different: %v and %v", args, r1.GetValue(), r2.GetValue())
+		}
+	}
+
+	if lazy.Query("friends", 1, "name").GetString() != "Mary" {
+		t.Fatalf("the value is %v, expected Mary", lazy.Query("friends", 1, "name").GetString())
+	}
+	results, err := lazy.QueryAll("$..age")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("len(results) is %v, expected 3", len(results))
+	}
+
+	s, _ := jsonsearcher.NewWithOptions([]byte(` [ {"a\"b" : 1, "c" : [ true , null ] } ] `), jsonsearcher.Options{Lazy: true})
+	if s.Query(0, `a"b`).GetInt64() != 1 {
+		t.Fatalf("the value is %v, expected 1", s.Query(0, `a"b`).GetValue())
+	}
+	if !s.Query(0, "c", 0).GetBool() {
+		t.Fatalf("the value is false, expected true")
+	}
+	if s.Query(0, "c", 1).Type() != jsonsearcher.TypeNull {
+		t.Fatalf("the type is %v, expected TypeNull", s.Query(0, "c", 1).Type())
+	}
+
+	invalid := []string{``, `{`, `{"a":1,}`, `[1,]`, `[1 2]`, `{"a" 1}`, `{a:1}`, `"\x"`, "\"\t\"", `01`, `1.`, `-`, `1e`, `tru`, `nul`, `{} {}`, `[1]]`}
+	for _, data := range invalid {
+		if _, err := jsonsearcher.NewWithOptions([]byte(data), jsonsearcher.Options{Lazy: true}); err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", data)
+		}
+		if _, err := jsonsearcher.New([]byte(data)); err == nil {
+			t.Fatalf("err is nil for %v in eager mode, expected not nil", data)
+		}
+	}
+}
+
+// deepString nests far more arrays than the scanners accept, it must not overflow the stack
+var deepString = `{"a":` + strings.Repeat("[", 20000000)
+
+func TestDeepNesting(t *testing.T) {
+	if _, err := jsonsearcher.NewWithOptions([]byte(deepString), jsonsearcher.Options{Lazy: true}); err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Fatalf("err is %v, expected exceeding max depth", err)
+	}
+	if _, err := jsonsearcher.NewWithOptions([]byte(deepString), jsonsearcher.Options{MaxKeys: 10}); err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Fatalf("err is %v, expected exceeding max depth", err)
+	}
+
+	ss := jsonsearcher.NewFromReader(strings.NewReader(deepString))
+	ss.Register("b")
+	if err := ss.Search(); err == nil || !strings.Contains(err.Error(), "max depth") {
+		t.Fatalf("err is %v, expected exceeding max depth", err)
+	}
+
+	sc := jsonsearcher.NewScanner(strings.NewReader(deepString), jsonsearcher.ScannerOptions{Multiline: true})
+	if sc.Scan() || sc.Err() == nil || !strings.Contains(sc.Err().Error(), "max depth") {
+		t.Fatalf("err is %v, expected exceeding max depth", sc.Err())
+	}
+
+	// The max depth itself is accepted
+	data := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	if _, err := jsonsearcher.NewWithOptions([]byte(data), jsonsearcher.Options{Lazy: true}); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+}
+
+func TestLazyFloatRange(t *testing.T) {
+	data := []byte(`{"a":[1e308,-1e-400],"b":1e400}`)
+	var syntaxErr *jsonsearcher.SyntaxError
+	if _, err := jsonsearcher.NewWithOptions(data, jsonsearcher.Options{Lazy: true}); !errors.As(err, &syntaxErr) || syntaxErr.Offset != 25 {
+		t.Fatalf("err is %v, expected *jsonsearcher.SyntaxError at 25", err)
+	}
+	if _, err := jsonsearcher.New(data); err == nil {
+		t.Fatalf("err is nil in eager mode, expected not nil")
+	}
+
+	// The literals are kept by UseNumber, a number out of the range of float64 can not be read by Float64
+	for _, lazy := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions(data, jsonsearcher.Options{Lazy: lazy, UseNumber: true})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		var numErr *jsonsearcher.NumberError
+		if _, err := s.Query("b").Float64(); !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Literal != "1e400" {
+			t.Fatalf("err is %v, expected overflow error", err)
+		}
+		if out, err := s.Marshal(); err != nil || string(out) != string(data) {
+			t.Fatalf("the json is %s %v, expected %s", out, err, data)
+		}
+	}
+}
+
+func TestLazyDuplicateKeys(t *testing.T) {
+	for _, data := range []string{`{"a":1,"b":{"a":3},"a":2}`, `{"a":1,"a":2}`, `{"a":1,"\u0061":2}`} {
+		for _, opts := range []jsonsearcher.Options{{Lazy: true}, {KeepRaw: true}} {
+			s, _ := jsonsearcher.NewWithOptions([]byte(data), opts)
+			if v := s.Query("a").GetInt64(); v != 2 {
+				t.Fatalf("the value of %s is %v, expected 2", data, v)
+			}
+			if r, err := s.QueryPath("$.a"); err != nil || string(r.Raw()) != "2" {
+				t.Fatalf("the raw of %s is %s %v, expected 2", data, r.Raw(), err)
+			}
+			if v := s.Query().GetObject()["a"]; v != float64(2) {
+				t.Fatalf("the value of %s is %v, expected 2", data, v)
+			}
+		}
+	}
+
+	// Duplicate keys are found in big objects and in nested objects after the outer one continues
+	var b strings.Builder
+	b.WriteString(`{"x":{"a":0}`)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, `,"k%d":{"a":%d}`, i, i)
+	}
+	b.WriteString(`,"k50":1}`)
+	s, _ := jsonsearcher.NewWithOptions([]byte(b.String()), jsonsearcher.Options{Lazy: true})
+	if v := s.Query("k50").GetInt64(); v != 1 {
+		t.Fatalf("the value is %v, expected 1", v)
+	}
+	s, _ = jsonsearcher.NewWithOptions([]byte(`{"a":{"x":1},"b":{"x":2},"a":3}`), jsonsearcher.Options{Lazy: true})
+	if v := s.Query("a").GetInt64(); v != 3 {
+		t.Fatalf("the value is %v, expected 3", v)
+	}
+}
@@ -0,0 +1,110 @@
+package searchertest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestLimits(t *testing.T) {
+	cases := []struct {
+		data   string
+		opts   jsonsearcher.Options
+		limit  string
+		line   int
+		column int
+	}{
+		{`{"a":[1,[2]]}`, jsonsearcher.Options{MaxDepth: 2}, "MaxDepth", 1, 9},
+		{strings.Repeat("[", 100000) + strings.Repeat("]", 100000), jsonsearcher.Options{MaxDepth: 64}, "MaxDepth", 1, 65},
+		{`{"a":"12345"}`, jsonsearcher.Options{MaxSize: 10}, "MaxSize", 1, 11},
+		{"{\"a\":\n\"123456\"}", jsonsearcher.Options{MaxStringLength: 5}, "MaxStringLength", 2, 1},
+		{`{"abcdef":1}`, jsonsearcher.Options{MaxStringLength: 5}, "MaxStringLength", 1, 2},
+		{`{"a":1,"b":2,"c":3}`, jsonsearcher.Options{MaxKeys: 2}, "MaxKeys", 1, 14},
+		{`{"a":1,"a":2}`, jsonsearcher.Options{MaxKeys: 1}, "MaxKeys", 1, 8},
+	}
+	for _, c := range cases {
+		for _, lazy := range []bool{false, true} {
+			c.opts.Lazy = lazy
+			_, err := jsonsearcher.NewWithOptions([]byte(c.data), c.opts)
+			var limitErr *jsonsearcher.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("err is %v, expected a *LimitError", err)
+			}
+			if limitErr.Limit != c.limit || limitErr.Line != c.line || limitErr.Column != c.column {
+				t.Fatalf("err is %v, expected %v exceeded at %v:%v", err, c.limit, c.line, c.column)
+			}
+		}
+	}
+
+	// The values within the limits are accepted
+	opts := jsonsearcher.Options{MaxDepth: 2, MaxSize: 40, MaxStringLength: 5, MaxKeys: 2}
+	for _, data := range []string{`{"a":[1,2]}`, `{"a":"中"}`, `{"a":"\u4e2d"}`, `[{"a":1,"b":2},{"c":"12345"}]`, `"12345"`, `1`} {
+		if _, err := jsonsearcher.NewWithOptions([]byte(data), opts); err != nil {
+			t.Fatalf("err of %v is %v, expected nil", data, err)
+		}
+	}
+
+	// Syntax errors are still reported as they are
+	_, err := jsonsearcher.NewWithOptions([]byte(`{"a":[1,}`), opts)
+	var syntaxErr *jsonsearcher.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Column != 9 {
+		t.Fatalf("err is %v, expected a *SyntaxError at column 9", err)
+	}
+}
+
+const duplicateString = "{\"a\":1,\"b\":{\"x\":1,\"x\":2},\n\"a\":{\"y\":[1]},\"c\":3,\"a\":3}"
+
+func TestDuplicateKeys(t *testing.T) {
+	cases := []struct {
+		policy jsonsearcher.DuplicateKeyPolicy
+		a      string
+		x      string
+		keys   string
+		json   string
+	}{
+		{jsonsearcher.LastWins, "3", "2", "b,c,a", `{"b":{"x":2},"c":3,"a":3}`},
+		{jsonsearcher.FirstWins, "1", "1", "a,b,c", `{"a":1,"b":{"x":1},"c":3}`},
+	}
+	for _, c := range cases {
+		for _, lazy := range []bool{false, true} {
+			s, err := jsonsearcher.NewWithOptions([]byte(duplicateString), jsonsearcher.Options{Lazy: lazy, KeepRaw: true, DuplicateKeys: c.policy})
+			if err != nil {
+				t.Fatalf("err is %v, expected nil", err)
+			}
+			if raw := string(s.Query("a").Raw()); raw != c.a {
+				t.Fatalf("a is %v, expected %v", raw, c.a)
+			}
+			if raw := string(s.Query("b", "x").Raw()); raw != c.x {
+				t.Fatalf("b.x is %v, expected %v", raw, c.x)
+			}
+			if keys := s.Query().Keys(); strings.Join(keys, ",") != c.keys {
+				t.Fatalf("the keys are %v, expected %v", keys, c.keys)
+			}
+			data, _ := s.Marshal()
+			if string(data) != c.json {
+				t.Fatalf("the json is %s, expected one value of each key", data)
+			}
+			if pos, ok := s.Query("c").Position(); !ok || pos.String() != "2:19" {
+				t.Fatalf("the position is %v %v, expected 2:19", pos, ok)
+			}
+		}
+	}
+
+	for _, policy := range []jsonsearcher.DuplicateKeyPolicy{jsonsearcher.LastWins, jsonsearcher.FirstWins} {
+		s, err := jsonsearcher.NewWithOptions([]byte(`{"a":1, "a":2 ,"a":3}`), jsonsearcher.Options{Lazy: true, DuplicateKeys: policy})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if v := s.Query("a").GetInt64(); len(s.Query().Keys()) != 1 || v == 2 {
+			t.Fatalf("a is %v, expected the first or the last", v)
+		}
+	}
+
+	_, err := jsonsearcher.NewWithOptions([]byte(duplicateString), jsonsearcher.Options{DuplicateKeys: jsonsearcher.ErrorOnDuplicate})
+	var dupErr *jsonsearcher.DuplicateKeyError
+	if !errors.As(err, &dupErr) || dupErr.Path.String() != "$.b.x" || dupErr.Line != 1 || dupErr.Column != 19 {
+		t.Fatalf("err is %v, expected duplicate $.b.x at 1:19", err)
+	}
+}
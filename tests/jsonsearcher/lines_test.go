@@ -0,0 +1,126 @@
+package searchertest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const linesString = `{"level":"info","msg":"start"}
+{"level":"warn","msg":"slow"} {"level":"info","msg":"same line"}
+
+{"level":"error","msg":"broken"
+{"level":"info","msg":"end"}`
+
+func TestScanner(t *testing.T) {
+	level := jsonsearcher.MustCompile("$.level")
+	msg := jsonsearcher.MustCompile("$.msg")
+
+	for _, lazy := range []bool{false, true} {
+		sc := jsonsearcher.NewScanner(iotest.OneByteReader(strings.NewReader(linesString)), jsonsearcher.ScannerOptions{
+			Options:   jsonsearcher.Options{Lazy: lazy},
+			Malformed: jsonsearcher.SkipMalformed,
+		})
+		var msgs []string
+		var lines []int
+		for sc.Scan() {
+			if level.First(sc.Searcher()).GetString() == "" {
+				t.Fatalf("the level is empty at line %v", sc.Line())
+			}
+			msgs = append(msgs, msg.First(sc.Searcher()).GetString())
+			lines = append(lines, sc.Line())
+		}
+		if err := sc.Err(); err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if strings.Join(msgs, ",") != "start,slow,same line,end" {
+			t.Fatalf("the messages are %v, expected [start slow same line end]", msgs)
+		}
+		if len(lines) != 4 || lines[0] != 1 || lines[1] != 2 || lines[2] != 2 || lines[3] != 5 {
+			t.Fatalf("the lines are %v, expected [1 2 2 5]", lines)
+		}
+		if sc.Skipped() != 1 {
+			t.Fatalf("skipped is %v, expected 1", sc.Skipped())
+		}
+	}
+}
+
+func TestScannerMalformed(t *testing.T) {
+	sc := jsonsearcher.NewScanner(strings.NewReader(linesString), jsonsearcher.ScannerOptions{})
+	n := 0
+	for sc.Scan() {
+		n++
+	}
+	var lineErr *jsonsearcher.LineError
+	if !errors.As(sc.Err(), &lineErr) || lineErr.Line != 4 || n != 3 {
+		t.Fatalf("err is %v after %v documents, expected malformed json at line 4 after 3 documents", sc.Err(), n)
+	}
+
+	sc = jsonsearcher.NewScanner(strings.NewReader(linesString+"\nnot json\n"), jsonsearcher.ScannerOptions{
+		Malformed: jsonsearcher.ReportMalformed,
+	})
+	var malformed []int
+	n = 0
+	for sc.Scan() {
+		if err := sc.Malformed(); err != nil {
+			if sc.Searcher() != nil || !errors.As(err, &lineErr) || lineErr.Line != sc.Line() {
+				t.Fatalf("the malformed document is %v, expected a *LineError without searcher", err)
+			}
+			malformed = append(malformed, sc.Line())
+			continue
+		}
+		n++
+	}
+	if sc.Err() != nil || n != 4 || len(malformed) != 2 || malformed[0] != 4 || malformed[1] != 6 {
+		t.Fatalf("the result is %v %v %v, expected 4 documents and malformed lines [4 6]", sc.Err(), n, malformed)
+	}
+}
+
+func TestScannerMultiline(t *testing.T) {
+	data := "{\n  \"a\": 1\n}\n[1,\n 2] \"s\"\n{\"b\":\n"
+	sc := jsonsearcher.NewScanner(strings.NewReader(data), jsonsearcher.ScannerOptions{
+		Multiline: true,
+		Malformed: jsonsearcher.ReportMalformed,
+	})
+	var types []string
+	var lines []int
+	for sc.Scan() {
+		if sc.Malformed() != nil {
+			types = append(types, "malformed")
+		} else {
+			types = append(types, sc.Searcher().Query().Type().String())
+		}
+		lines = append(lines, sc.Line())
+	}
+	if strings.Join(types, ",") != "ObjectType,ArrayType,StringType,malformed" {
+		t.Fatalf("the types are %v, expected [ObjectType ArrayType StringType malformed]", types)
+	}
+	if len(lines) != 4 || lines[0] != 1 || lines[1] != 4 || lines[2] != 5 || lines[3] != 6 {
+		t.Fatalf("the lines are %v, expected [1 4 5 6]", lines)
+	}
+}
+
+func TestScannerMultilineLarge(t *testing.T) {
+	// An indented document of many lines is validated in linear time, it took minutes when it was
+	// rescanned on every line
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for i := 0; i < 200000; i++ {
+		sb.WriteString(`  {"id": 1, "s": "[{\""},` + "\n")
+	}
+	sb.WriteString("  {\"id\": 2}\n]\n{\"next\": true}\n")
+
+	sc := jsonsearcher.NewScanner(strings.NewReader(sb.String()), jsonsearcher.ScannerOptions{Multiline: true})
+	if !sc.Scan() || sc.Searcher().Query(200000, "id").GetInt64() != 2 || sc.Searcher().Query(0, "s").GetString() != `[{"` {
+		t.Fatalf("the document is not scanned, err is %v", sc.Err())
+	}
+	if !sc.Scan() || !sc.Searcher().Query("next").GetBool() || sc.Line() != 200004 {
+		t.Fatalf("the next document is not scanned at line 200004, err is %v", sc.Err())
+	}
+	if sc.Scan() || sc.Err() != nil {
+		t.Fatalf("err is %v, expected the end of input", sc.Err())
+	}
+}
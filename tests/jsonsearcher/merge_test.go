@@ -0,0 +1,126 @@
+package searchertest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestMerge(t *testing.T) {
+	// The examples of RFC 7396
+	cases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		base, _ := jsonsearcher.New([]byte(c[0]))
+		overlay, _ := jsonsearcher.New([]byte(c[1]))
+		data, _ := jsonsearcher.Merge(base, overlay).Marshal()
+		if string(data) != c[2] {
+			t.Fatalf("the merged document of %v and %v is %s, expected %v", c[0], c[1], data, c[2])
+		}
+		if before, _ := base.Marshal(); string(before) != c[0] {
+			t.Fatalf("the base is modified to %s, expected %v", before, c[0])
+		}
+	}
+}
+
+func TestMergeLayers(t *testing.T) {
+	defaults, _ := jsonsearcher.New([]byte(`{"server":{"host":"localhost","port":80},"log":{"level":"info"},"users":[{"name":"a","role":"user"}]}`))
+	env, _ := jsonsearcher.New([]byte(`{"server":{"port":8080},"log":null,"users":[{"name":"b","role":"user"}]}`))
+	tenant, _ := jsonsearcher.New([]byte(`{"server":{"host":"tenant.example.com"},"users":[{"name":"a","role":"admin"}]}`))
+
+	s := jsonsearcher.Merge(defaults, env, tenant)
+	data, _ := s.Marshal()
+	if string(data) != `{"server":{"host":"tenant.example.com","port":8080},"users":[{"name":"a","role":"admin"}]}` {
+		t.Fatalf("the merged document is %s", data)
+	}
+	origins := map[string]int{
+		"$.server.host":   2,
+		"$.server.port":   1,
+		"$.server":        2,
+		"$.users[0].role": 2,
+		"$.users[0].name": 2,
+	}
+	for path, expected := range origins {
+		r, _ := s.QueryPath(path)
+		if layer, ok := r.Origin(); !ok || layer != expected {
+			t.Fatalf("the origin of %v is %v %v, expected %v", path, layer, ok, expected)
+		}
+	}
+
+	s = jsonsearcher.MergeWithOptions(jsonsearcher.MergeOptions{Arrays: jsonsearcher.ArrayAppend}, defaults, env)
+	data, _ = s.Marshal()
+	if string(data) != `{"server":{"host":"localhost","port":8080},"users":[{"name":"a","role":"user"},{"name":"b","role":"user"}]}` {
+		t.Fatalf("the merged document is %s", data)
+	}
+	if layer, _ := s.Query("users", 0, "role").Origin(); layer != 0 {
+		t.Fatalf("the origin is %v, expected 0", layer)
+	}
+	if layer, _ := s.Query("users", 1, "role").Origin(); layer != 1 {
+		t.Fatalf("the origin is %v, expected 1", layer)
+	}
+
+	s = jsonsearcher.MergeWithOptions(jsonsearcher.MergeOptions{Arrays: jsonsearcher.ArrayMergeByKey, Key: "name"}, defaults, env, tenant)
+	data, _ = s.Marshal()
+	if string(data) != `{"server":{"host":"tenant.example.com","port":8080},"users":[{"name":"a","role":"admin"},{"name":"b","role":"user"}]}` {
+		t.Fatalf("the merged document is %s", data)
+	}
+	if layer, _ := s.Query("users", 0, "role").Origin(); layer != 2 {
+		t.Fatalf("the origin is %v, expected 2", layer)
+	}
+	if layer, _ := s.Query("users", 1).Origin(); layer != 1 {
+		t.Fatalf("the origin is %v, expected 1", layer)
+	}
+
+	if _, ok := s.Query("undefined").Origin(); ok {
+		t.Fatalf("the origin of a missing value is reported")
+	}
+	if _, ok := defaults.Query("server").Origin(); ok {
+		t.Fatalf("the origin of a searcher not merged is reported")
+	}
+	s.Set(jsonsearcher.Path{"server", "port"}, 1)
+	if _, ok := s.Query("server").Origin(); ok {
+		t.Fatalf("the origin is reported after the document is modified")
+	}
+}
+
+func TestMergeManyKeys(t *testing.T) {
+	var base, overlay strings.Builder
+	base.WriteString(`{"x":0`)
+	overlay.WriteString(`{"x":{"y":1}`)
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&base, `,"k%d":{"a":%d}`, i, i)
+		fmt.Fprintf(&overlay, `,"k%d":%d`, i, i)
+	}
+	base.WriteString("}")
+	overlay.WriteString("}")
+	b, _ := jsonsearcher.New([]byte(base.String()))
+	o, _ := jsonsearcher.New([]byte(overlay.String()))
+	last, _ := jsonsearcher.New([]byte(`{"k1":{"a":2},"x":{"z":2}}`))
+	m := jsonsearcher.Merge(b, o, o, last)
+	if layer, ok := m.Query("k0").Origin(); !ok || layer != 2 {
+		t.Fatalf("the origin is %v %v, expected 2", layer, ok)
+	}
+	if layer, ok := m.Query("k1", "a").Origin(); !ok || layer != 3 {
+		t.Fatalf("the origin is %v %v, expected 3", layer, ok)
+	}
+	if layer, ok := m.Query("x", "y").Origin(); !ok || layer != 2 {
+		t.Fatalf("the origin is %v %v, expected 2", layer, ok)
+	}
+}
@@ -0,0 +1,132 @@
+package searchertest
+
+import (
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestMutate(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(`{"name":"Markity","friends":[{"name":"Jack"}],"phone":null}`), jsonsearcher.Options{KeepOrder: true})
+
+	check := func(expected string) {
+		data, err := s.Marshal()
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if string(data) != expected {
+			t.Fatalf("the document is %s, expected %s", data, expected)
+		}
+	}
+
+	if err := s.Set(jsonsearcher.Path{"name"}, "Mary"); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if s.Query("name").GetString() != "Mary" {
+		t.Fatalf("the value is %v, expected Mary", s.Query("name").GetValue())
+	}
+	if err := s.Set(jsonsearcher.Path{"friends", 0, "age"}, 17); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if s.Query("friends", 0, "age").GetInt64() != 17 {
+		t.Fatalf("the value is %v, expected 17", s.Query("friends", 0, "age").GetValue())
+	}
+	if err := s.Set(jsonsearcher.Path{"details", "interests", 1}, "python"); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	check(`{"name":"Mary","friends":[{"name":"Jack","age":17}],"phone":null,"details":{"interests":[null,"python"]}}`)
+
+	if err := s.Insert(jsonsearcher.Path{"details", "interests"}, 0, "golang"); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Delete(jsonsearcher.Path{"details", "interests", 1}); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Append(jsonsearcher.Path{"details", "interests"}, "rust"); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Append(jsonsearcher.Path{"tags"}, map[string]int{"a": 1}); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Delete(jsonsearcher.Path{"phone"}); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Set(jsonsearcher.Path{"friends", 1}, s.Query("friends", 0)); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if err := s.Set(jsonsearcher.Path{"friends", 1, "name"}, "Tom"); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	check(`{"name":"Mary","friends":[{"name":"Jack","age":17},{"name":"Tom","age":17}],"details":{"interests":["golang","python","rust"]},"tags":[{"a":1}]}`)
+
+	data, err := s.MarshalIndent("", " ")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if string(data[:4]) != "{\n \"" {
+		t.Fatalf("the document is %s, expected indented", data)
+	}
+
+	failures := []func() error{
+		func() error { return s.Delete(jsonsearcher.Path{"undefined"}) },
+		func() error { return s.Delete(jsonsearcher.Path{"friends", 5}) },
+		func() error { return s.Delete(jsonsearcher.Path{"friends", "a"}) },
+		func() error { return s.Delete(jsonsearcher.Path{}) },
+		func() error { return s.Delete(jsonsearcher.Path{"a", "b", "c"}) },
+		func() error { return s.Set(jsonsearcher.Path{"name", "first"}, 1) },
+		func() error { return s.Set(jsonsearcher.Path{"friends", -1}, 1) },
+		func() error { return s.Set(jsonsearcher.Path{"friends", "a"}, 1) },
+		func() error { return s.Insert(jsonsearcher.Path{"friends"}, 3, 1) },
+		func() error { return s.Insert(jsonsearcher.Path{"name"}, 0, 1) },
+		func() error { return s.Append(jsonsearcher.Path{"details"}, 1) },
+		func() error { return s.Set(jsonsearcher.Path{"x"}, func() {}) },
+	}
+	before, _ := s.Marshal()
+	for i, f := range failures {
+		if err := f(); err == nil {
+			t.Fatalf("err is nil for case %v, expected not nil", i)
+		}
+	}
+	after, _ := s.Marshal()
+	if string(before) != string(after) {
+		t.Fatalf("the document is %s after failures, expected %s", after, before)
+	}
+
+	if err := s.Set(jsonsearcher.Path{}, []int{1, 2}); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	check(`[1,2]`)
+
+	lazy, _ := jsonsearcher.NewWithOptions([]byte(`{"a":[1]}`), jsonsearcher.Options{Lazy: true})
+	if err := lazy.Append(jsonsearcher.Path{"a"}, 2); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if lazy.Query("a", 1).GetInt64() != 2 {
+		t.Fatalf("the value is %v, expected 2", lazy.Query("a", 1).GetValue())
+	}
+}
+
+func TestMutateFailureKeepsRaw(t *testing.T) {
+	for _, opts := range []jsonsearcher.Options{{Lazy: true}, {KeepRaw: true}} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(`{"a":1.50,"b":[1]}`), opts)
+		if err := s.Delete(jsonsearcher.Path{"b", 5}); err == nil {
+			t.Fatalf("err is nil, expected not nil")
+		}
+		if string(s.Query("a").Raw()) != "1.50" {
+			t.Fatalf("the raw is %s, expected 1.50", s.Query("a").Raw())
+		}
+		if pos, ok := s.Query("a").Position(); !ok || pos.String() != "1:6" {
+			t.Fatalf("the position is %v %v, expected 1:6", pos, ok)
+		}
+	}
+
+	base, _ := jsonsearcher.New([]byte(`{"a":1}`))
+	overlay, _ := jsonsearcher.New([]byte(`{"b":2}`))
+	m := jsonsearcher.Merge(base, overlay)
+	if err := m.Set(jsonsearcher.Path{"a", "x"}, 1); err == nil {
+		t.Fatalf("err is nil, expected not nil")
+	}
+	if layer, ok := m.Query("b").Origin(); !ok || layer != 1 {
+		t.Fatalf("the origin is %v %v, expected 1", layer, ok)
+	}
+}
@@ -0,0 +1,123 @@
+package searchertest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const numberString = `{"id":9007199254740993,"big":123456789012345678901234567890,"price":19.99,"ratio":1.9,"exp":1.5e3,"tiny":-2.5E-3,"neg":-1}`
+
+func TestUseNumber(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions([]byte(numberString), jsonsearcher.Options{UseNumber: true, Lazy: lazy, KeepOrder: true})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		if s.Query("id").Type() != jsonsearcher.TypeNumber {
+			t.Fatalf("the type is %v, expected NumberType", s.Query("id").Type())
+		}
+		if v, err := s.Query("id").Int64(); err != nil || v != 9007199254740993 {
+			t.Fatalf("the value is %v %v, expected 9007199254740993", v, err)
+		}
+		if v := s.Query("id").GetUint64(); v != 9007199254740993 {
+			t.Fatalf("the value is %v, expected 9007199254740993", v)
+		}
+		if v, err := s.Query("big").BigInt(); err != nil || v.String() != "123456789012345678901234567890" {
+			t.Fatalf("the value is %v %v, expected 123456789012345678901234567890", v, err)
+		}
+		if v, err := s.Query("price").BigFloat(); err != nil || v.Text('f', 2) != "19.99" {
+			t.Fatalf("the value is %v %v, expected 19.99", v, err)
+		}
+		if v, err := s.Query("price").Float64(); err != nil || v != 19.99 {
+			t.Fatalf("the value is %v %v, expected 19.99", v, err)
+		}
+		if v, err := s.Query("exp").Decimal(); err != nil || v != "1500" {
+			t.Fatalf("the value is %v %v, expected 1500", v, err)
+		}
+		if v, err := s.Query("tiny").Decimal(); err != nil || v != "-0.0025" {
+			t.Fatalf("the value is %v %v, expected -0.0025", v, err)
+		}
+		if v, err := s.Query("exp").Int64(); err != nil || v != 1500 {
+			t.Fatalf("the value is %v %v, expected 1500", v, err)
+		}
+
+		data, err := s.Marshal()
+		if err != nil || string(data) != `{"id":9007199254740993,"big":123456789012345678901234567890,"price":19.99,"ratio":1.9,"exp":1.5e3,"tiny":-2.5E-3,"neg":-1}` {
+			t.Fatalf("the json is %s %v, expected the literals kept", data, err)
+		}
+
+		r, err := s.QueryPath("$[?(@ == 9007199254740993)]")
+		if err != nil || r.Path().String() != "$.id" {
+			t.Fatalf("the result is %v %v, expected $.id", r.Path(), err)
+		}
+		if rs, err := s.QueryAll("$[?(@ > 9007199254740992)]"); err != nil || len(rs) != 2 {
+			t.Fatalf("the results are %v %v, expected $.big and $.id", len(rs), err)
+		}
+	}
+}
+
+func TestNumberError(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(numberString), jsonsearcher.Options{UseNumber: true})
+
+	var numErr *jsonsearcher.NumberError
+	_, err := s.Query("ratio").Int64()
+	if !errors.As(err, &numErr) || numErr.Reason != "fractional part" || numErr.Literal != "1.9" || numErr.Target != "int64" {
+		t.Fatalf("err is %v, expected fractional part error", err)
+	}
+	_, err = s.Query("big").Int64()
+	if !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Path.String() != "$.big" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	_, err = s.Query("neg").Uint64()
+	if !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Target != "uint64" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	_, err = s.Query("price").BigInt()
+	if !errors.As(err, &numErr) || numErr.Reason != "fractional part" {
+		t.Fatalf("err is %v, expected fractional part error", err)
+	}
+
+	var typeErr *jsonsearcher.TypeError
+	if _, err = s.Query("missing").BigInt(); !errors.As(err, &typeErr) {
+		t.Fatalf("err is %v, expected *jsonsearcher.TypeError", err)
+	}
+
+	// The accessors of the default mode report fractional parts too, GetInt64 keeps truncating
+	d, _ := jsonsearcher.New([]byte(numberString))
+	if _, err = d.Query("ratio").Int64(); !errors.As(err, &numErr) {
+		t.Fatalf("err is %v, expected *jsonsearcher.NumberError", err)
+	}
+	if v := d.Query("ratio").GetInt64(); v != 1 {
+		t.Fatalf("the value is %v, expected 1", v)
+	}
+}
+
+func TestHugeExponent(t *testing.T) {
+	s, err := jsonsearcher.NewWithOptions([]byte(`{"huge":1e1000000000,"tiny":-1.5e-1000000000,"zero":0.0e1000000000}`), jsonsearcher.Options{UseNumber: true})
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	var numErr *jsonsearcher.NumberError
+	if _, err := s.Query("huge").Decimal(); !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Target != "decimal" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	if _, err := s.Query("huge").Int64(); !errors.As(err, &numErr) || numErr.Reason != "overflow" || numErr.Target != "int64" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	if _, err := s.Query("huge").BigFloat(); !errors.As(err, &numErr) || numErr.Reason != "overflow" {
+		t.Fatalf("err is %v, expected overflow error", err)
+	}
+	if _, err := s.Query("tiny").BigInt(); !errors.As(err, &numErr) || numErr.Reason != "underflow" {
+		t.Fatalf("err is %v, expected underflow error", err)
+	}
+	if v, err := s.Query("zero").Decimal(); err != nil || v != "0" {
+		t.Fatalf("the value is %v %v, expected 0", v, err)
+	}
+	if v, err := s.Query("zero").Int64(); err != nil || v != 0 {
+		t.Fatalf("the value is %v %v, expected 0", v, err)
+	}
+}
@@ -0,0 +1,177 @@
+package searchertest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const orderString = `{"zeta":1,"alpha":{"y":true,"x":null},"mid":[{"b":1,"a":2},"s"],"beta":"b"}`
+
+func TestKeys(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(orderString), jsonsearcher.Options{Lazy: lazy, KeepOrder: true})
+
+		if keys := s.Query().Keys(); !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid", "beta"}) {
+			t.Fatalf("keys are %v, expected [zeta alpha mid beta]", keys)
+		}
+		if keys := s.Query("mid", 0).Keys(); !reflect.DeepEqual(keys, []string{"b", "a"}) {
+			t.Fatalf("keys are %v, expected [b a]", keys)
+		}
+		if keys := s.Query("mid").Keys(); keys != nil {
+			t.Fatalf("keys are %v, expected nil", keys)
+		}
+		if keys := s.Query("missing").Keys(); keys != nil {
+			t.Fatalf("keys are %v, expected nil", keys)
+		}
+
+		r, _ := s.QueryPath("$.alpha")
+		if keys := r.Keys(); !reflect.DeepEqual(keys, []string{"y", "x"}) {
+			t.Fatalf("keys are %v, expected [y x]", keys)
+		}
+	}
+}
+
+func TestKeysBigObject(t *testing.T) {
+	// The keys of a big object are found by an index, the duplicate and escaped keys keep their
+	// first position
+	var members, expected []string
+	for i := 99; i >= 0; i-- {
+		members = append(members, fmt.Sprintf(`"k%d":%d`, i, i))
+		expected = append(expected, fmt.Sprintf("k%d", i))
+	}
+	members = append(members, `"k\u0035":{"b":1,"a":2}`, `"k50":0`, `"obj":{"d":1,"c":2}`)
+	expected = append(expected, "obj")
+	data := "{" + strings.Join(members, ",") + "}"
+
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(data), jsonsearcher.Options{Lazy: lazy, KeepOrder: true})
+		if keys := s.Query().Keys(); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("keys are %v, expected %v", keys, expected)
+		}
+		if keys := s.Query("obj").Keys(); !reflect.DeepEqual(keys, []string{"d", "c"}) {
+			t.Fatalf("keys are %v, expected [d c]", keys)
+		}
+	}
+}
+
+func TestForEach(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(orderString), jsonsearcher.Options{Lazy: lazy, KeepOrder: true})
+
+		var keys []string
+		var paths []string
+		s.Query().ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			keys = append(keys, key.GetString())
+			paths = append(paths, value.Path().String())
+			return true
+		})
+		if !reflect.DeepEqual(keys, []string{"zeta", "alpha", "mid", "beta"}) {
+			t.Fatalf("keys are %v, expected [zeta alpha mid beta]", keys)
+		}
+		if !reflect.DeepEqual(paths, []string{"$.zeta", "$.alpha", "$.mid", "$.beta"}) {
+			t.Fatalf("paths are %v, expected [$.zeta $.alpha $.mid $.beta]", paths)
+		}
+
+		var indexes []int64
+		var types []string
+		s.Query("mid").ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			indexes = append(indexes, key.GetInt64())
+			types = append(types, value.Type().String())
+			return true
+		})
+		if !reflect.DeepEqual(indexes, []int64{0, 1}) || !reflect.DeepEqual(types, []string{"ObjectType", "StringType"}) {
+			t.Fatalf("elements are %v %v, expected [0 1] [ObjectType StringType]", indexes, types)
+		}
+
+		n := 0
+		s.Query().ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			n++
+			return key.GetString() != "alpha"
+		})
+		if n != 2 {
+			t.Fatalf("n is %v, expected 2", n)
+		}
+
+		s.Query("beta").ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			t.Fatalf("ForEach of a string calls fn")
+			return true
+		})
+	}
+}
+
+func TestOrderAfterMutation(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(orderString), jsonsearcher.Options{KeepOrder: true})
+	_ = s.Set(jsonsearcher.Path{"gamma"}, 3)
+	_ = s.Set(jsonsearcher.Path{"aa"}, 4)
+	_ = s.Delete(jsonsearcher.Path{"alpha"})
+	if keys := s.Query().Keys(); !reflect.DeepEqual(keys, []string{"zeta", "mid", "beta", "gamma", "aa"}) {
+		t.Fatalf("keys are %v, expected [zeta mid beta gamma aa]", keys)
+	}
+
+	_ = s.ApplyPatch([]byte(`[{"op":"replace","path":"/zeta","value":0}]`))
+	if keys := s.Query().Keys(); !reflect.DeepEqual(keys, []string{"zeta", "mid", "beta", "gamma", "aa"}) {
+		t.Fatalf("keys are %v, expected [zeta mid beta gamma aa]", keys)
+	}
+
+	overlay, _ := jsonsearcher.NewWithOptions([]byte(`{"new2":1,"mid":null,"new1":{"q":1,"p":2}}`), jsonsearcher.Options{KeepOrder: true})
+	m := jsonsearcher.Merge(s, overlay)
+	if keys := m.Query().Keys(); !reflect.DeepEqual(keys, []string{"zeta", "beta", "gamma", "aa", "new2", "new1"}) {
+		t.Fatalf("keys are %v, expected [zeta beta gamma aa new2 new1]", keys)
+	}
+	if keys := m.Query("new1").Keys(); !reflect.DeepEqual(keys, []string{"q", "p"}) {
+		t.Fatalf("keys are %v, expected [q p]", keys)
+	}
+}
+
+func TestOrderedOutput(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(orderString), jsonsearcher.Options{Lazy: lazy, KeepOrder: true})
+
+		all, _ := s.QueryAll("$.*")
+		var paths []string
+		for _, r := range all {
+			paths = append(paths, r.Path().String())
+		}
+		if strings.Join(paths, ",") != "$.zeta,$.alpha,$.mid,$.beta" {
+			t.Fatalf("the paths are %v, expected [$.zeta $.alpha $.mid $.beta]", paths)
+		}
+
+		_ = s.Set(jsonsearcher.Path{"gamma"}, s.Query("mid", 0))
+		data, _ := s.Marshal()
+		if string(data) != `{"zeta":1,"alpha":{"y":true,"x":null},"mid":[{"b":1,"a":2},"s"],"beta":"b","gamma":{"b":1,"a":2}}` {
+			t.Fatalf("the json is %s, expected in document order", data)
+		}
+		data, _ = s.Query("alpha").Render(jsonsearcher.RenderOptions{})
+		if string(data) != `{"y":true,"x":null}` {
+			t.Fatalf("the json is %s, expected in document order", data)
+		}
+	}
+
+	a, _ := jsonsearcher.NewWithOptions([]byte(`{"z":1,"a":2}`), jsonsearcher.Options{KeepRaw: true})
+	b, _ := jsonsearcher.NewWithOptions([]byte(`{"y":{"d":1,"c":2},"x":3}`), jsonsearcher.Options{KeepRaw: true})
+	patch, _ := jsonsearcher.GeneratePatch(a, b)
+	if string(patch) != `[{"op":"remove","path":"/z"},{"op":"remove","path":"/a"},{"op":"add","path":"/y","value":{"d":1,"c":2}},{"op":"add","path":"/x","value":3}]` {
+		t.Fatalf("the patch is %s, expected in document order", patch)
+	}
+}
+
+func TestKeysUnknownOrder(t *testing.T) {
+	// An eager searcher knows the order only if it keeps the data or KeepOrder is set
+	for _, c := range []struct {
+		opts     jsonsearcher.Options
+		expected []string
+	}{
+		{jsonsearcher.Options{}, []string{"alpha", "beta", "mid", "zeta"}},
+		{jsonsearcher.Options{KeepRaw: true}, []string{"zeta", "alpha", "mid", "beta"}},
+		{jsonsearcher.Options{KeepRaw: true, KeepOrder: true}, []string{"zeta", "alpha", "mid", "beta"}},
+	} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(orderString), c.opts)
+		if keys := s.Query().Keys(); !reflect.DeepEqual(keys, c.expected) {
+			t.Fatalf("keys are %v, expected %v", keys, c.expected)
+		}
+	}
+}
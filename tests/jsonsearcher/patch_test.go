@@ -0,0 +1,100 @@
+package searchertest
+
+import (
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestApplyPatch(t *testing.T) {
+	cases := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"/":1,"~":2}`, `[{"op":"remove","path":"/~1"},{"op":"replace","path":"/~0","value":3}]`, `{"~":3}`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{`{"0":"a"}`, `[{"op":"add","path":"/0","value":"b"}]`, `{"0":"b"}`},
+	}
+	for _, c := range cases {
+		s, _ := jsonsearcher.New([]byte(c.doc))
+		if err := s.ApplyPatch([]byte(c.patch)); err != nil {
+			t.Fatalf("err is %v for %v, expected nil", err, c.patch)
+		}
+		data, _ := s.Marshal()
+		if string(data) != c.expected {
+			t.Fatalf("the document is %s after %v, expected %s", data, c.patch, c.expected)
+		}
+	}
+
+	failures := []string{
+		`{}`,
+		`[{"op":"add","path":"/a/b","value":1}]`,
+		`[{"op":"remove","path":"/undefined"}]`,
+		`[{"op":"replace","path":"/undefined","value":1}]`,
+		`[{"op":"add","path":"/arr/5","value":1}]`,
+		`[{"op":"add","path":"/arr/01","value":1}]`,
+		`[{"op":"move","from":"/obj","path":"/obj/child"}]`,
+		`[{"op":"test","path":"/name","value":"Mary"}]`,
+		`[{"op":"add","path":"/x"}]`,
+		`[{"op":"unknown","path":"/x"}]`,
+		`[{"op":"add","path":"x","value":1}]`,
+		`[{"op":"remove","path":"/~2"}]`,
+		`[{"op":"add","path":"/new","value":1},{"op":"test","path":"/new","value":2}]`,
+	}
+	for _, patch := range failures {
+		s, _ := jsonsearcher.NewWithOptions([]byte(`{"name":"Jack","arr":[1,2],"obj":{"a":1}}`), jsonsearcher.Options{KeepOrder: true})
+		if err := s.ApplyPatch([]byte(patch)); err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", patch)
+		}
+		data, _ := s.Marshal()
+		if string(data) != `{"name":"Jack","arr":[1,2],"obj":{"a":1}}` {
+			t.Fatalf("the document is %s after a failed patch, expected not modified", data)
+		}
+	}
+}
+
+func TestGeneratePatch(t *testing.T) {
+	pairs := [][2]string{
+		{`{"a":1,"b":[1,2,3],"c":{"d":"e"},"f":null}`, `{"a":2,"b":[1,5],"c":{"d":"e","g":true},"h":[]}`},
+		{`[1,2]`, `[1,2,3,4]`},
+		{`{"a":1}`, `[1]`},
+		{`{"a/b":{"~":1}}`, `{"a/b":{"~":2}}`},
+		{`{"a":1}`, `{"a":1}`},
+	}
+	for _, pair := range pairs {
+		a, _ := jsonsearcher.New([]byte(pair[0]))
+		b, _ := jsonsearcher.New([]byte(pair[1]))
+		patch, err := jsonsearcher.GeneratePatch(a, b)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if err := a.ApplyPatch(patch); err != nil {
+			t.Fatalf("err is %v for %s, expected nil", err, patch)
+		}
+		got, _ := a.Marshal()
+		expected, _ := b.Marshal()
+		if string(got) != string(expected) {
+			t.Fatalf("the document is %s after %s, expected %s", got, patch, expected)
+		}
+	}
+
+	a, _ := jsonsearcher.New([]byte(`{"a":1,"b":2}`))
+	b, _ := jsonsearcher.New([]byte(`{"a":1,"c":3}`))
+	patch, _ := jsonsearcher.GeneratePatch(a, b)
+	if string(patch) != `[{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":3}]` {
+		t.Fatalf("the patch is %s, expected [{\"op\":\"remove\",\"path\":\"/b\"},{\"op\":\"add\",\"path\":\"/c\",\"value\":3}]", patch)
+	}
+}
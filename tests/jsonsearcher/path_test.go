@@ -0,0 +1,165 @@
+package searchertest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+func TestQueryPath(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(`
+{
+	"name":"Markity",
+	"friends":[
+		{"name":"Jack","age":17},
+		{"name":"Mary","age":18,"email":"3402002560@qq.com"}
+	],
+	"a.b":{"c d":1},
+	"it's":true
+}
+`))
+
+	exists := map[string]interface{}{
+		"$.name":                  "Markity",
+		"name":                    "Markity",
+		"$.friends[1].email":      "3402002560@qq.com",
+		"friends[0].name":         "Jack",
+		"$['friends'][0]['name']": "Jack",
+		`$["friends"][1]["age"]`:  float64(18),
+		"$['a.b']['c d']":         float64(1),
+		`$["a.b"].c`:              nil,
+		`$['it\'s']`:              true,
+		`$[ 'friends' ][ 1 ].age`: float64(18),
+		"$.friends[-1].name":      "Mary",
+		"$.friends[-2].age":       float64(17),
+		"$.friends[-3]":           nil,
+		"$.name[-1]":              nil,
+	}
+	for path, expected := range exists {
+		r, err := s.QueryPath(path)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil for %v", err, path)
+		}
+		if expected == nil {
+			if r.Exists() {
+				t.Fatalf("the value of %v exists, expected not exist", path)
+			}
+			continue
+		}
+		if !r.Exists() {
+			t.Fatalf("the value of %v does not exist, expected exist", path)
+		}
+		if r.GetValue() != expected {
+			t.Fatalf("the value of %v is %v, expected %v", path, r.GetValue(), expected)
+		}
+	}
+
+	r, err := s.QueryPath("$")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if r.Type() != jsonsearcher.TypeObject {
+		t.Fatalf("r.Type is %v, expected TypeObject", r.Type())
+	}
+
+	r, err = s.QueryPath("$.friends[2]")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if r.Exists() {
+		t.Fatalf("the value exists, expected not exist")
+	}
+
+	all, _ := s.QueryAll("$.friends[-1]")
+	if len(all) != 1 || all[0].Path().String() != "$.friends[1]" {
+		t.Fatalf("the results are %v, expected $.friends[1]", all)
+	}
+	if r := jsonsearcher.MustCompile("$.friends[-1].age").First(s); r.GetValue() != float64(18) {
+		t.Fatalf("the value is %v, expected 18", r.GetValue())
+	}
+
+	invalid := []string{
+		"$.",
+		"$[-]",
+		"$..",
+		"$[",
+		"$[1",
+		"$['name'",
+		"$['name]",
+		"$[name]",
+		"$.friends[x]",
+		"$.friends]",
+		"$name",
+		`$['\q']`,
+		"$['a'] b",
+	}
+	for _, path := range invalid {
+		_, err := s.QueryPath(path)
+		if err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", path)
+		}
+		var pathErr *jsonsearcher.PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("err is %T, expected *jsonsearcher.PathError", err)
+		}
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	s, _ := jsonsearcher.New([]byte(jsonString))
+
+	paths := func(results []*jsonsearcher.Result) string {
+		strs := make([]string, 0, len(results))
+		for _, r := range results {
+			strs = append(strs, fmt.Sprintf("%v=%v", r.Path(), r.GetValue()))
+		}
+		return strings.Join(strs, " ")
+	}
+
+	cases := map[string]string{
+		"friends[*].name":     "$.friends[0].name=Jack $.friends[1].name=Mary",
+		"$.friends.*.age":     "$.friends[0].age=17 $.friends[1].age=18",
+		"$..email":            "$.friends[1].email=3402002560@qq.com",
+		"$..name":             "$.name=Markity $.friends[0].name=Jack $.friends[1].name=Mary",
+		"$.details..[0]":      "$.details.interests[0]=golang",
+		"$..interests[*]":     "$.details.interests[0]=golang $.details.interests[1]=python",
+		"$.details.*":         "$.details.interests=[golang python]",
+		"$.friends[1]['age']": "$.friends[1].age=18",
+		"$.undefined[*]":      "",
+		"$.name.*":            "",
+	}
+	for path, expected := range cases {
+		results, err := s.QueryAll(path)
+		if err != nil {
+			t.Fatalf("err is %v, expected nil for %v", err, path)
+		}
+		if paths(results) != expected {
+			t.Fatalf("the results of %v are %v, expected %v", path, paths(results), expected)
+		}
+		for _, r := range results {
+			if s.Query(r.Path()...).GetValue() == nil {
+				t.Fatalf("the path %v can not be queried", r.Path())
+			}
+		}
+	}
+
+	r, err := s.QueryPath("$.friends[*].age")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if r.GetInt64() != 17 {
+		t.Fatalf("r.GetInt64() is %v, expected 17", r.GetInt64())
+	}
+
+	if _, err := s.QueryAll("$..."); err == nil {
+		t.Fatalf("err is nil, expected not nil")
+	}
+
+	p := jsonsearcher.Path{"a b", 1, "it's", "c"}
+	if p.String() != `$['a b'][1]['it\'s'].c` {
+		t.Fatalf("p.String() is %v, expected $['a b'][1]['it\\'s'].c", p.String())
+	}
+}
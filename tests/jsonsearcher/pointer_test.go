@@ -0,0 +1,59 @@
+package searchertest
+
+import (
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+// pointerString is the example document of RFC 6901
+const pointerString = `{"foo":["bar","baz"],"":0,"a/b":1,"c%d":2,"e^f":3,"g|h":4,"i\\j":5,"k\"l":6," ":7,"m~n":8,"01":{"1":9}}`
+
+func TestQueryPointer(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(pointerString), jsonsearcher.Options{Lazy: lazy})
+
+		for ptr, expected := range map[string]interface{}{
+			"/foo/0": "bar",
+			"/":      float64(0),
+			"/a~1b":  float64(1),
+			"/c%d":   float64(2),
+			"/e^f":   float64(3),
+			"/g|h":   float64(4),
+			"/i\\j":  float64(5),
+			"/k\"l":  float64(6),
+			"/ ":     float64(7),
+			"/m~0n":  float64(8),
+			"/01/1":  float64(9),
+		} {
+			r, err := s.QueryPointer(ptr)
+			if err != nil || !r.Exists() || r.GetValue() != expected {
+				t.Fatalf("the value of %q is %v %v, expected %v", ptr, r.GetValue(), err, expected)
+			}
+			if r.Pointer() != ptr {
+				t.Fatalf("the pointer is %q, expected %q", r.Pointer(), ptr)
+			}
+		}
+
+		if r, err := s.QueryPointer(""); err != nil || r.Type() != jsonsearcher.TypeObject || r.Pointer() != "" {
+			t.Fatalf("the root is %v %v, expected the whole document", r.Type(), err)
+		}
+		for _, ptr := range []string{"/foo/2", "/foo/-", "/foo/01", "/foo/bar", "/missing/x", "/01/1/x"} {
+			if r, err := s.QueryPointer(ptr); err != nil || r.Exists() {
+				t.Fatalf("the result of %q exists %v %v, expected not exist", ptr, r.Exists(), err)
+			}
+		}
+		for _, ptr := range []string{"foo", "/m~2n", "/m~"} {
+			if _, err := s.QueryPointer(ptr); err == nil {
+				t.Fatalf("err of %q is nil, expected invalid pointer", ptr)
+			}
+		}
+
+		if r, _ := s.Query("foo").QueryPointer("/1"); r.GetString() != "baz" || r.Pointer() != "/foo/1" {
+			t.Fatalf("the value is %v at %v, expected baz at /foo/1", r.GetValue(), r.Pointer())
+		}
+		if p := (jsonsearcher.Path{"a/b", 1, "m~n"}).Pointer(); p != "/a~1b/1/m~0n" {
+			t.Fatalf("the pointer is %v, expected /a~1b/1/m~0n", p)
+		}
+	}
+}
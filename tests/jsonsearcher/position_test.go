@@ -0,0 +1,108 @@
+package searchertest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const positionString = "\n  {\n  \"name\": \"Markity\",\n  \"friends\": [\n    {\"name\": \"张三\", \"age\": 20}\n  ]\n}\n"
+
+func TestPosition(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions([]byte(positionString), jsonsearcher.Options{Lazy: lazy, KeepRaw: true})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		cases := []struct {
+			path jsonsearcher.Path
+			pos  jsonsearcher.Position
+		}{
+			{jsonsearcher.Path{}, jsonsearcher.Position{Offset: 3, Line: 2, Column: 3}},
+			{jsonsearcher.Path{"name"}, jsonsearcher.Position{Offset: 15, Line: 3, Column: 11}},
+			{jsonsearcher.Path{"friends", 0}, jsonsearcher.Position{Offset: 45, Line: 5, Column: 5}},
+			{jsonsearcher.Path{"friends", 0, "age"}, jsonsearcher.Position{Offset: 71, Line: 5, Column: 31}},
+		}
+		for _, c := range cases {
+			pos, ok := s.Query(c.path...).Position()
+			if !ok || pos != c.pos {
+				t.Fatalf("the position of %v is %+v %v, expected %+v", c.path, pos, ok, c.pos)
+			}
+		}
+
+		all, _ := s.QueryAll("$..age")
+		if pos, ok := all[0].Position(); !ok || pos.String() != "5:31" {
+			t.Fatalf("the position is %v %v, expected 5:31", pos, ok)
+		}
+		if _, ok := s.Query("missing").Position(); ok {
+			t.Fatalf("ok is true, expected false for a missing result")
+		}
+		var positions []string
+		s.Query("friends").ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			pos, _ := value.Query("name").Position()
+			positions = append(positions, pos.String())
+			return true
+		})
+		if len(positions) != 1 || positions[0] != "5:14" {
+			t.Fatalf("the positions are %v, expected [5:14]", positions)
+		}
+	}
+
+	s, _ := jsonsearcher.New([]byte(positionString))
+	if _, ok := s.Query("name").Position(); ok {
+		t.Fatalf("ok is true, expected false without KeepRaw")
+	}
+	s, _ = jsonsearcher.NewWithOptions([]byte(positionString), jsonsearcher.Options{KeepRaw: true})
+	s.Set(jsonsearcher.Path{"name"}, "M")
+	if _, ok := s.Query("name").Position(); ok {
+		t.Fatalf("ok is true, expected false after mutation")
+	}
+}
+
+func TestSyntaxErrorDeepNesting(t *testing.T) {
+	_, err := jsonsearcher.New([]byte(deepString))
+	var syntaxErr *jsonsearcher.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 1 || syntaxErr.Column != 10005 {
+		t.Fatalf("err is %v, expected exceeding max depth at line 1, column 10005", err)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	cases := []struct {
+		data    string
+		line    int
+		column  int
+		snippet string
+	}{
+		{"{\n  \"a\": 1,\n  \"b\": tru\n}", 3, 8, `  "b": tru`},
+		{"[1, 2,]", 1, 7, "[1, 2,]"},
+		{"{\"a\": 1} x", 1, 10, `{"a": 1} x`},
+		{"{\"a\":", 1, 6, `{"a":`},
+		{"[\"" + "abcdefghijklmnopqrstuvwxyz0123456789" + "\" x]", 1, 41, `opqrstuvwxyz0123456789" x]`},
+	}
+	for _, c := range cases {
+		for _, lazy := range []bool{false, true} {
+			_, err := jsonsearcher.NewWithOptions([]byte(c.data), jsonsearcher.Options{Lazy: lazy})
+			var syntaxErr *jsonsearcher.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("err is %v, expected a *SyntaxError", err)
+			}
+			if syntaxErr.Line != c.line || syntaxErr.Column != c.column || syntaxErr.Snippet != c.snippet {
+				t.Fatalf("the error of %q is %v, expected line %v, column %v near %q", c.data, err, c.line, c.column, c.snippet)
+			}
+		}
+	}
+}
+
+func BenchmarkEagerPosition(b *testing.B) {
+	s, _ := jsonsearcher.NewWithOptions(bigJSON, jsonsearcher.Options{KeepRaw: true})
+	r := s.Query("friends", 49999, "email")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := r.Position(); !ok {
+			b.Fatal("unexpected position")
+		}
+	}
+}
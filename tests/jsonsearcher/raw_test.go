@@ -0,0 +1,114 @@
+package searchertest
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+const rawString = ` {"name":"Markity", "friends":[ {"z":1.50, "a":"<b>&"}, {"z":2} ], "n":1e3} `
+
+func TestRaw(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, err := jsonsearcher.NewWithOptions([]byte(rawString), jsonsearcher.Options{Lazy: lazy, KeepRaw: true})
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+
+		if raw := s.Query("friends", 0).Raw(); string(raw) != `{"z":1.50, "a":"<b>&"}` {
+			t.Fatalf("the raw is %s, expected the original bytes", raw)
+		}
+		if raw := s.Query().Raw(); string(raw) != rawString[1:len(rawString)-1] {
+			t.Fatalf("the raw is %s, expected the document without the outer spaces", raw)
+		}
+		if raw := s.Query("n").Raw(); string(raw) != "1e3" {
+			t.Fatalf("the raw is %s, expected 1e3", raw)
+		}
+		sum, _ := jsonsearcher.Sum([]*jsonsearcher.Result{s.Query("n")})
+		if raw := sum.Raw(); string(raw) != "1000" {
+			t.Fatalf("the raw is %s, expected 1000", raw)
+		}
+		if raw := s.Query("missing").Raw(); raw != nil {
+			t.Fatalf("the raw is %s, expected nil", raw)
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"friend": s.Query("friends", 0)})
+		if err != nil || string(data) != `{"friend":{"z":1.50,"a":"\u003cb\u003e\u0026"}}` {
+			t.Fatalf("the json is %s %v, expected the friend embedded", data, err)
+		}
+		if _, err := json.Marshal(s.Query("missing")); err == nil {
+			t.Fatalf("err is nil, expected the result does not exist")
+		}
+
+		data, err = s.Query("friends").Render(jsonsearcher.RenderOptions{})
+		if err != nil || string(data) != `[{"z":1.50,"a":"<b>&"},{"z":2}]` {
+			t.Fatalf("the json is %s %v, expected compact", data, err)
+		}
+		data, err = s.Query("friends", 0).Render(jsonsearcher.RenderOptions{Indent: "  ", EscapeHTML: true})
+		if err != nil || string(data) != "{\n  \"z\": 1.50,\n  \"a\": \"\\u003cb\\u003e\\u0026\"\n}" {
+			t.Fatalf("the json is %s %v, expected indented and escaped", data, err)
+		}
+	}
+}
+
+func TestRawMutated(t *testing.T) {
+	s, _ := jsonsearcher.NewWithOptions([]byte(rawString), jsonsearcher.Options{KeepRaw: true})
+	if err := s.Set(jsonsearcher.Path{"friends", 1, "z"}, 3); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if raw := s.Query("friends", 1).Raw(); string(raw) != `{"z":3}` {
+		t.Fatalf("the raw is %s, expected the encoding of the mutated value", raw)
+	}
+	data, err := s.Query("friends", 0).Render(jsonsearcher.RenderOptions{Prefix: ">", Indent: "\t"})
+	if err != nil || string(data) != "{\n>\t\"z\": 1.5,\n>\t\"a\": \"<b>&\"\n>}" {
+		t.Fatalf("the json is %q %v, expected indented", data, err)
+	}
+
+	ages, _ := s.QueryAll("$.friends[*].z")
+	sum, _ := jsonsearcher.Sum(ages)
+	if raw := sum.Raw(); string(raw) != "4.5" {
+		t.Fatalf("the raw is %s, expected 4.5", raw)
+	}
+}
+
+func TestRawOffset(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		s, _ := jsonsearcher.NewWithOptions([]byte(rawString), jsonsearcher.Options{Lazy: lazy, KeepRaw: true})
+		var raws []string
+		s.Query("friends").ForEach(func(key jsonsearcher.Result, value *jsonsearcher.Result) bool {
+			raws = append(raws, string(value.Query("z").Raw()))
+			return true
+		})
+		if len(raws) != 2 || raws[0] != "1.50" || raws[1] != "2" {
+			t.Fatalf("the raws are %v, expected [1.50 2]", raws)
+		}
+
+		// The offset of a result is found once, and the result can be used by multiple goroutines
+		r := s.Query("friends", 1)
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if raw := r.Raw(); string(raw) != `{"z":2}` {
+					t.Errorf("the raw is %s, expected {\"z\":2}", raw)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+}
+
+func BenchmarkEagerRaw(b *testing.B) {
+	s, _ := jsonsearcher.NewWithOptions(bigJSON, jsonsearcher.Options{KeepRaw: true})
+	r := s.Query("friends", 49999, "email")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if string(r.Raw()) != `"friend49999@qq.com"` {
+			b.Fatal("unexpected raw")
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package schematest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+	"github.com/markity/goutils/jsonsearcher/schema"
+)
+
+const userSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["name", "age", "friends"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"phone": {"type": ["string", "null"]},
+		"friends": {"type": "array", "items": {"$ref": "#/$defs/friend"}, "maxItems": 3},
+		"tags": {"type": "array", "uniqueItems": true, "prefixItems": [{"const": "first"}]}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"friend": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"}
+			}
+		}
+	}
+}`
+
+func violations(t *testing.T, err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	var ve *schema.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("err is %v, expected *schema.ValidationError", err)
+	}
+	m := make(map[string]string)
+	for _, v := range ve.Violations {
+		m[v.InstancePath.String()] = v.SchemaPath
+	}
+	return m
+}
+
+func TestValidate(t *testing.T) {
+	sch, err := schema.Compile([]byte(userSchema))
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if sch.Draft() != schema.Draft202012 {
+		t.Fatalf("draft is %v, expected 2020-12", sch.Draft())
+	}
+
+	valid, _ := jsonsearcher.New([]byte(`{"name":"Markity","age":16,"phone":null,"friends":[{"name":"a","email":"a@qq.com"}],"tags":["first","x"]}`))
+	if err := sch.Validate(valid); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	invalid, _ := jsonsearcher.New([]byte(`{"name":"","age":16.5,"friends":[{"email":"bad"},{"name":1}],"tags":["second","x","x"],"extra":true}`))
+	got := violations(t, sch.Validate(invalid))
+	expected := map[string]string{
+		"$.name":             "#/properties/name/minLength",
+		"$.age":              "#/properties/age/type",
+		"$.friends[0]":       "#/$defs/friend/required",
+		"$.friends[0].email": "#/$defs/friend/properties/email/pattern",
+		"$.friends[1].name":  "#/$defs/friend/properties/name/type",
+		"$.tags":             "#/properties/tags/uniqueItems",
+		"$.tags[0]":          "#/properties/tags/prefixItems/0/const",
+		"$.extra":            "#/additionalProperties",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("violations are %v, expected %v", got, expected)
+	}
+	for path, schemaPath := range expected {
+		if got[path] != schemaPath {
+			t.Fatalf("violation of %v is %v, expected %v", path, got[path], schemaPath)
+		}
+	}
+
+	// A Result is validated as a document
+	friend, _ := schema.Compile([]byte(`{"type":"object","required":["name"]}`))
+	if err := friend.Validate(valid.Query("friends", 0)); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	got = violations(t, friend.Validate(invalid.Query("friends", 0)))
+	if got["$.friends[0]"] != "#/required" {
+		t.Fatalf("violations are %v, expected $.friends[0] #/required", got)
+	}
+}
+
+func TestDraft07(t *testing.T) {
+	sch, err := schema.Compile([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"definitions": {"positive": {"type": "number", "exclusiveMinimum": 0}},
+		"type": "object",
+		"properties": {
+			"point": {"type": "array", "items": [{"$ref": "#/definitions/positive"}, {"type": "string"}], "additionalItems": false},
+			"list": {"items": {"$ref": "#/definitions/positive"}}
+		},
+		"dependencies": {"card": ["billing"]}
+	}`))
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if sch.Draft() != schema.Draft07 {
+		t.Fatalf("draft is %v, expected draft-07", sch.Draft())
+	}
+
+	s, _ := jsonsearcher.New([]byte(`{"point":[0,"a",true],"list":[1,-1],"card":1}`))
+	got := violations(t, sch.Validate(s))
+	expected := map[string]string{
+		"$.point[0]": "#/definitions/positive/exclusiveMinimum",
+		"$.point[2]": "#/properties/point/additionalItems",
+		"$.list[1]":  "#/definitions/positive/exclusiveMinimum",
+		"$":          "#/dependencies",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("violations are %v, expected %v", got, expected)
+	}
+	for path, schemaPath := range expected {
+		if got[path] != schemaPath {
+			t.Fatalf("violation of %v is %v, expected %v", path, got[path], schemaPath)
+		}
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	sch, _ := schema.Compile([]byte(`{
+		"properties": {
+			"any": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+			"one": {"oneOf": [{"multipleOf": 2}, {"multipleOf": 3}]},
+			"not": {"not": {"enum": [1, 2]}},
+			"cond": {"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}},
+			"names": {"propertyNames": {"pattern": "^[a-z]+$"}},
+			"contains": {"contains": {"const": 1}, "minContains": 2}
+		}
+	}`))
+
+	s, _ := jsonsearcher.New([]byte(`{"any":"x","one":4,"not":3,"cond":20,"names":{"ok":1},"contains":[1,2,1]}`))
+	if err := sch.Validate(s); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	s, _ = jsonsearcher.New([]byte(`{"any":1.5,"one":6,"not":2,"cond":15,"names":{"Bad":1},"contains":[1,2]}`))
+	got := violations(t, sch.Validate(s))
+	expected := map[string]string{
+		"$.any":       "#/properties/any/anyOf",
+		"$.one":       "#/properties/one/oneOf",
+		"$.not":       "#/properties/not/not",
+		"$.cond":      "#/properties/cond/then/multipleOf",
+		"$.names.Bad": "#/properties/names/propertyNames",
+		"$.contains":  "#/properties/contains/contains",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("violations are %v, expected %v", got, expected)
+	}
+	for path, schemaPath := range expected {
+		if got[path] != schemaPath {
+			t.Fatalf("violation of %v is %v, expected %v", path, got[path], schemaPath)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, src := range []string{
+		`{"$schema":"http://json-schema.org/draft-04/schema#"}`,
+		`{"$ref":"http://example.com/schema"}`,
+		`{"$ref":"#/$defs/missing"}`,
+		`{"type":"str"}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"properties":{"a":1}}`,
+		`{"$ref":"#"}`,
+		`{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+		`{"allOf":[{"$ref":"#"}]}`,
+		`{"$defs":{"a":{"anyOf":[{"not":{"$ref":"#/$defs/a"}}]}},"$ref":"#/$defs/a"}`,
+		`{"dependentSchemas":{"x":{"if":{"$ref":"#"}}}}`,
+	} {
+		_, err := schema.Compile([]byte(src))
+		var ce *schema.CompileError
+		if !errors.As(err, &ce) {
+			t.Fatalf("err of %v is %v, expected *schema.CompileError", src, err)
+		}
+	}
+}
+
+func TestRecursiveRef(t *testing.T) {
+	// A $ref back to the root is fine if it moves to a child value
+	sch, err := schema.Compile([]byte(`{"type":"object","properties":{"child":{"$ref":"#"}},"additionalProperties":false}`))
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	s, _ := jsonsearcher.New([]byte(`{"child":{"child":{"x":1}}}`))
+	got := violations(t, sch.Validate(s))
+	if len(got) != 1 || got["$.child.child.x"] != "#/additionalProperties" {
+		t.Fatalf("violations are %v, expected the one of $.child.child.x", got)
+	}
+}
+
+func TestUnevaluated(t *testing.T) {
+	cases := []struct {
+		schema   string
+		doc      string
+		expected map[string]string
+	}{
+		{`{"unevaluatedProperties":false,"properties":{"a":{}}}`, `{"a":1}`, nil},
+		{`{"unevaluatedProperties":false,"properties":{"a":{}}}`, `{"a":1,"b":2}`, map[string]string{"$.b": "#/unevaluatedProperties"}},
+		{`{"unevaluatedProperties":{"type":"string"},"patternProperties":{"^x":{}}}`, `{"x1":1,"b":"s","c":3}`, map[string]string{"$.c": "#/unevaluatedProperties/type"}},
+		// The annotations of the in-place subschemas count, but not the ones of the failed schemas
+		{`{"unevaluatedProperties":false,"allOf":[{"properties":{"a":{}}}],"$ref":"#/$defs/b","$defs":{"b":{"properties":{"b":{}}}}}`, `{"a":1,"b":2}`, nil},
+		{`{"unevaluatedProperties":false,"anyOf":[{"properties":{"a":{"type":"string"}}},{"properties":{"b":{}}}]}`, `{"a":1,"b":2}`, map[string]string{"$.a": "#/unevaluatedProperties"}},
+		{`{"unevaluatedProperties":false,"if":{"properties":{"a":{"const":1}}},"then":{"properties":{"b":{}}}}`, `{"a":1,"b":2}`, nil},
+		{`{"unevaluatedProperties":false,"additionalProperties":true}`, `{"a":1}`, nil},
+		{`{"unevaluatedItems":false,"prefixItems":[{}]}`, `[1]`, nil},
+		{`{"unevaluatedItems":false,"prefixItems":[{}]}`, `[1,2]`, map[string]string{"$[1]": "#/unevaluatedItems"}},
+		{`{"unevaluatedItems":{"type":"string"},"contains":{"type":"integer"}}`, `[1,"a",true]`, map[string]string{"$[2]": "#/unevaluatedItems/type"}},
+		{`{"unevaluatedItems":false,"allOf":[{"items":{}}]}`, `[1,2]`, nil},
+	}
+	for _, c := range cases {
+		sch, err := schema.Compile([]byte(c.schema))
+		if err != nil {
+			t.Fatalf("err of %v is %v, expected nil", c.schema, err)
+		}
+		s, _ := jsonsearcher.New([]byte(c.doc))
+		got := violations(t, sch.Validate(s))
+		if len(got) != len(c.expected) {
+			t.Fatalf("violations of %v for %v are %v, expected %v", c.doc, c.schema, got, c.expected)
+		}
+		for path, schemaPath := range c.expected {
+			if got[path] != schemaPath {
+				t.Fatalf("violation of %v is %v, expected %v", path, got[path], schemaPath)
+			}
+		}
+	}
+}
+
+func TestHugeExponent(t *testing.T) {
+	sch, _ := schema.Compile([]byte(`{"properties":{"a":{"maximum":10},"b":{"type":"number"}}}`))
+	s, _ := jsonsearcher.NewWithOptions([]byte(`{"a":1e1000000000,"b":-1e1000000000}`), jsonsearcher.Options{UseNumber: true})
+	got := violations(t, sch.Validate(s))
+	if len(got) != 1 || got["$.a"] != "#/properties/a/maximum" {
+		t.Fatalf("violations are %v, expected the one of $.a", got)
+	}
+}
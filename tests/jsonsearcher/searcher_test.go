@@ -242,8 +242,8 @@ func TestC(t *testing.T) {
 	if !s.Query().Exists() {
 		t.Fatalf("the value does not exist, expected exist")
 	}
-	if s.Query().Type() != jsonsearcher.TypeObject {
-		t.Fatalf("the value type is not TypeObject, expected TypeObject")
+	if s.Query().Type() != jsonsearcher.TypeNull {
+		t.Fatalf("the value type is not TypeNull, expected TypeNull")
 	}
 	if s.Query(0).Exists() {
 		t.Fatalf("the value exists, expected not exist")
@@ -252,3 +252,54 @@ func TestC(t *testing.T) {
 		t.Fatalf("the value exists, expected not exist")
 	}
 }
+
+func TestD(t *testing.T) {
+	s, err := jsonsearcher.New([]byte(`[{"id":1},{"id":2}]`))
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if s.Query().Type() != jsonsearcher.TypeArray {
+		t.Fatalf("the value type is not TypeArray, expected TypeArray")
+	}
+	if s.Query(0, "id").GetInt64() != 1 {
+		t.Fatalf("s.Query(0, \"id\").GetInt64() is %v, expected 1", s.Query(0, "id").GetInt64())
+	}
+	if s.Query(2, "id").Exists() {
+		t.Fatalf("the value exists, expected not exist")
+	}
+	if s.Query("id").Exists() {
+		t.Fatalf("the value exists, expected not exist")
+	}
+	r, err := s.QueryPath("$[1].id")
+	if err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if r.GetInt64() != 2 {
+		t.Fatalf("r.GetInt64() is %v, expected 2", r.GetInt64())
+	}
+
+	scalars := map[string]string{
+		`"str"`: jsonsearcher.TypeString.String(),
+		`1.5`:   jsonsearcher.TypeNumber.String(),
+		`true`:  jsonsearcher.TypeBool.String(),
+		`null`:  jsonsearcher.TypeNull.String(),
+	}
+	for data, typ := range scalars {
+		s, err := jsonsearcher.New([]byte(data))
+		if err != nil {
+			t.Fatalf("err is %v, expected nil", err)
+		}
+		if s.Query().Type().String() != typ {
+			t.Fatalf("the value type of %v is %v, expected %v", data, s.Query().Type(), typ)
+		}
+		if s.Query("a").Exists() || s.Query(0).Exists() {
+			t.Fatalf("the value exists, expected not exist")
+		}
+	}
+
+	for _, data := range []string{``, `[1,]`, `"str`, `1 2`} {
+		if _, err := jsonsearcher.New([]byte(data)); err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", data)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package searchertest
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/markity/goutils/jsonsearcher"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read after all paths are found")
+}
+
+func TestStream(t *testing.T) {
+	ss := jsonsearcher.NewFromReader(strings.NewReader(jsonString))
+	ss.Register("name")
+	ss.Register("friends", 1, "email")
+	ss.Register("friends", 0)
+	ss.Register("details", "interests", 1)
+	ss.Register("phone")
+	ss.Register("friends", 5)
+	ss.Register("undefined", "a")
+	if err := ss.Search(); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+
+	if ss.Query("name").GetString() != "Markity" {
+		t.Fatalf("the value is %v, expected Markity", ss.Query("name").GetValue())
+	}
+	if ss.Query("friends", 1, "email").GetString() != "3402002560@qq.com" {
+		t.Fatalf("the value is %v, expected 3402002560@qq.com", ss.Query("friends", 1, "email").GetValue())
+	}
+	if ss.Query("friends", 0, "name").Exists() {
+		t.Fatalf("the value exists, expected not exist because it is not registered")
+	}
+	if ss.Query("friends", 0).GetObject()["name"] != "Jack" {
+		t.Fatalf("the value is %v, expected Jack", ss.Query("friends", 0).GetObject()["name"])
+	}
+	if ss.Query("details", "interests", 1).GetString() != "python" {
+		t.Fatalf("the value is %v, expected python", ss.Query("details", "interests", 1).GetValue())
+	}
+	if ss.Query("phone").Type() != jsonsearcher.TypeNull {
+		t.Fatalf("the type is %v, expected TypeNull", ss.Query("phone").Type())
+	}
+	if ss.Query("friends", 5).Exists() || ss.Query("undefined", "a").Exists() {
+		t.Fatalf("the value exists, expected not exist")
+	}
+
+	// The reading stops once all the paths are found
+	r := io.MultiReader(strings.NewReader(`{"a":{"b":[1,{"c":"found"}],"d":`), failingReader{})
+	ss = jsonsearcher.NewFromReader(r)
+	ss.Register("a", "b", 1, "c")
+	if err := ss.Search(); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if ss.Query("a", "b", 1, "c").GetString() != "found" {
+		t.Fatalf("the value is %v, expected found", ss.Query("a", "b", 1, "c").GetValue())
+	}
+
+	// The paths inside a value found are resolved from it, in any order of registering
+	for _, order := range [][][]interface{}{{{"a"}, {"a", "b", 1}, {"a", "c"}}, {{"a", "b", 1}, {"a", "c"}, {"a"}}} {
+		r = io.MultiReader(strings.NewReader(`{"a":{"b":[0,1]},"d":`), failingReader{})
+		ss = jsonsearcher.NewFromReader(r)
+		for _, path := range order {
+			ss.Register(path...)
+		}
+		ss.Register("d")
+		if err := ss.Search(); err == nil {
+			t.Fatalf("err is nil, expected the reading error")
+		}
+		if v := ss.Query("a", "b", 1).GetInt64(); v != 1 {
+			t.Fatalf("the value is %v, expected 1", v)
+		}
+		if len(ss.Query("a").GetObject()) != 1 || ss.Query("a", "c").Exists() {
+			t.Fatalf("the value is %v, expected {\"b\":[0,1]} without c", ss.Query("a").GetValue())
+		}
+	}
+	r = io.MultiReader(strings.NewReader(`{"a":{"b":1},`), failingReader{})
+	ss = jsonsearcher.NewFromReader(r)
+	ss.Register("a")
+	ss.Register("a", "b")
+	if err := ss.Search(); err != nil || ss.Query("a", "b").GetInt64() != 1 {
+		t.Fatalf("err is %v, expected nil and 1", err)
+	}
+
+	ss = jsonsearcher.NewFromReader(strings.NewReader(`[{"k\"ey":[true, false]}]`))
+	ss.Register(0, `k"ey`, 1)
+	if err := ss.Search(); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	if ss.Query(0, `k"ey`, 1).GetBool() {
+		t.Fatalf("the value is true, expected false")
+	}
+
+	invalid := []string{``, `{`, `{"a":1,}`, `[1 2]`, `{"a" 1}`, `{"x":tru}`, `{"x":"\q"}`, `{"x":01}`, `{} {}`, `{"b":1}}`}
+	for _, data := range invalid {
+		ss := jsonsearcher.NewFromReader(strings.NewReader(data))
+		ss.Register("z")
+		if err := ss.Search(); err == nil {
+			t.Fatalf("err is nil for %v, expected not nil", data)
+		}
+	}
+}
+
+// hugeStringReader generates {"skip":"aaa...","name":"x"} without holding the document
+type hugeStringReader struct {
+	head, tail string
+	n          int
+}
+
+func (r *hugeStringReader) Read(p []byte) (int, error) {
+	switch {
+	case r.head != "":
+		n := copy(p, r.head)
+		r.head = r.head[n:]
+		return n, nil
+	case r.n > 0:
+		n := len(p)
+		if n > r.n {
+			n = r.n
+		}
+		for i := 0; i < n; i++ {
+			p[i] = 'a'
+		}
+		r.n -= n
+		return n, nil
+	case r.tail != "":
+		n := copy(p, r.tail)
+		r.tail = r.tail[n:]
+		return n, nil
+	}
+	return 0, io.EOF
+}
+
+func TestStreamSkipHugeString(t *testing.T) {
+	const size = 64 << 20
+	ss := jsonsearcher.NewFromReader(&hugeStringReader{head: `{"skip":"`, tail: `","name":"x"}`, n: size})
+	ss.Register("name")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := ss.Search(); err != nil {
+		t.Fatalf("err is %v, expected nil", err)
+	}
+	runtime.ReadMemStats(&after)
+	if ss.Query("name").GetString() != "x" {
+		t.Fatalf("the value is %v, expected x", ss.Query("name").GetValue())
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > size/8 {
+		t.Fatalf("the allocated bytes is %v, expected the skipped string not to be kept", alloc)
+	}
+
+	invalid := map[string]string{
+		`{"x":"\q","z":1}`:         "offset 7",
+		`{"x":"\u12g4","z":1}`:     "offset 10",
+		"{\"x\":\"a\tb\",\"z\":1}": "offset 7",
+		`{"x":"abc`:                "offset 9",
+	}
+	for data, expected := range invalid {
+		ss := jsonsearcher.NewFromReader(strings.NewReader(data))
+		ss.Register("z")
+		err := ss.Search()
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("err is %v for %v, expected at %v", err, data, expected)
+		}
+	}
+}